@@ -16,5 +16,16 @@ var (
 )
 
 func main() {
-	terminate.InitAndExecute()
+	terminate.InitAndExecute(version())
+}
+
+// version returns the build tag, or the build commit if it does not match a tag
+func version() string {
+	if BuildTag != "" {
+		return BuildTag
+	}
+	if BuildCommit != "" {
+		return BuildCommit
+	}
+	return "unknown"
 }
@@ -0,0 +1,89 @@
+package terminate
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/terminate"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func newAuditCommand(flags *globalFlags) *cobra.Command {
+
+	var auditLog string
+	var since time.Duration
+	var name string
+	var resource string
+	var output string
+
+	cmd := &cobra.Command{
+		Use:           "audit [--since DURATION] [--resource TYPE] [--name NAME]",
+		Short:         "shows the records of the resources which were modified, from the audit log",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outputFormat, err := getOutputFormat(output)
+			if err != nil {
+				return err
+			}
+			if outputFormat == terminate.OutputName {
+				return fmt.Errorf(`invalid output format (%v). Must be "json", "yaml", or "wide"`, output)
+			}
+			if since < 0 {
+				return fmt.Errorf("invalid since value (%v). Must be greater than or equal to 0", since)
+			}
+			cfg, err := loadConfig(configFile())
+			if err != nil {
+				return err
+			}
+			filter := terminate.AuditFilter{
+				Namespace: flags.namespace(),
+				Name:      name,
+				Resource:  resource,
+			}
+			if since > 0 {
+				filter.Since = time.Now().Add(-since)
+			}
+			records, err := terminate.ReadAuditLog(auditLogPath(auditLog, cmd.Flags().Changed("audit-log"), cfg), filter)
+			if err != nil {
+				return err
+			}
+			return terminate.PrintAuditRecords(cmd.OutOrStdout(), outputFormat, records)
+		},
+	}
+	cmd.Flags().StringVarP(&auditLog, "audit-log", "", defaultAuditLog(), fmt.Sprintf("(optional) the audit log to read (overrides the 'audit.path' setting in %s)", configFile()))
+	cmd.Flags().DurationVarP(&since, "since", "", 0, "(optional) only show the records which are more recent than this duration (eg: 24h)")
+	cmd.Flags().StringVarP(&name, "name", "", "", "(optional) only show the records of the resources with this name")
+	cmd.Flags().StringVarP(&resource, "resource", "", "", "(optional) only show the records of the resources of this type, optionally qualified with its group (eg: 'pods' or 'deployments.apps')")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "(optional) output format of the records. One of: json|yaml|wide. By default, the records are printed in a table.")
+
+	return cmd
+}
+
+// newAuditLog returns the audit log at the given path, for the current user and the given context
+func newAuditLog(path, context string, kubeconfig clientcmd.ClientConfig) (*terminate.AuditLog, error) {
+	config, err := kubeconfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error while loading kubeconfig: %w", err)
+	}
+	return &terminate.AuditLog{
+		Path:        path,
+		User:        localUser(),
+		Context:     context,
+		Server:      config.Host,
+		ToolVersion: version,
+	}, nil
+}
+
+// localUser returns the name of the local user
+func localUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,105 @@
+package terminate_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xcoulon/kubectl-terminate/cmd/terminate"
+	pkgterminate "github.com/xcoulon/kubectl-terminate/pkg/terminate"
+	"github.com/xcoulon/kubectl-terminate/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditCmd(t *testing.T) {
+
+	// given
+	server := test.NewServer(t)
+	defer server.Close()
+	_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+	defer os.Remove(kubeconfig.Name())
+	_, resetHome := setTempHome(t)
+	defer resetHome()
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	auditLog := filepath.Join(dir, "audit.log")
+	_, err = executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--no-backup", "--audit-log="+auditLog, "pod", "cookie", "cookie2")
+	require.NoError(t, err)
+
+	t.Run("ok", func(t *testing.T) {
+
+		t.Run("all records", func(t *testing.T) {
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "audit", "--audit-log="+auditLog, "-o", "json")
+			// then
+			require.NoError(t, err)
+			records := []pkgterminate.AuditRecord{}
+			err = json.Unmarshal([]byte(out), &records)
+			require.NoError(t, err)
+			require.Len(t, records, 2)
+			assert.Equal(t, "cookie", records[0].Name)
+			assert.Equal(t, "cookie2", records[1].Name)
+			for _, r := range records {
+				assert.Equal(t, "test-server", r.Context)
+				assert.Equal(t, server.URL, r.Server)
+				assert.NotEmpty(t, r.User)
+				assert.Equal(t, "pods", r.Resource)
+				assert.Equal(t, "default", r.Namespace)
+				assert.Equal(t, []string{"cheesecake"}, r.FinalizersRemoved)
+				assert.Equal(t, pkgterminate.DeleteAccepted, r.DeleteResult)
+			}
+		})
+
+		t.Run("table", func(t *testing.T) {
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "audit", "--audit-log="+auditLog, "--name=cookie2", "--since=1h")
+			// then
+			require.NoError(t, err)
+			assert.Regexp(t, `^TIMESTAMP\s+USER\s+CONTEXT\s+NAMESPACE\s+NAME\s+RESOURCE\s+FINALIZERS REMOVED\s+DELETE\s+ERROR
+\S+\s+\S+\s+test-server\s+default\s+cookie2\s+pods\.v1\.\s+cheesecake\s+accepted\s+<none>
+$`, out)
+		})
+
+		t.Run("no matching record", func(t *testing.T) {
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "audit", "--audit-log="+auditLog, "--resource=deployments.apps", "-o", "json")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "[]\n", out)
+		})
+
+		t.Run("no record in dry-run", func(t *testing.T) {
+			// given
+			dryRunAuditLog := filepath.Join(dir, "dry-run.log")
+			_, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--dry-run=server", "--audit-log="+dryRunAuditLog, "pod/cookie")
+			require.NoError(t, err)
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "audit", "--audit-log="+dryRunAuditLog, "-o", "json")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "[]\n", out)
+		})
+	})
+
+	t.Run("failures", func(t *testing.T) {
+
+		t.Run("invalid output format", func(t *testing.T) {
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "audit", "--audit-log="+auditLog, "-o", "name")
+			// then
+			require.EqualError(t, err, `invalid output format (name). Must be "json", "yaml", or "wide"`)
+		})
+
+		t.Run("invalid since", func(t *testing.T) {
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "audit", "--audit-log="+auditLog, "--since=-1h")
+			// then
+			require.EqualError(t, err, "invalid since value (-1h0m0s). Must be greater than or equal to 0")
+		})
+	})
+}
@@ -0,0 +1,87 @@
+package terminate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/terminate"
+
+	"github.com/spf13/viper"
+	"k8s.io/client-go/util/homedir"
+)
+
+// configFile returns the path to the configuration file, ie, `~/.config/kubectl-terminate/config.yaml`
+func configFile() string {
+	return filepath.Join(homedir.HomeDir(), ".config", "kubectl-terminate", "config.yaml")
+}
+
+// config the settings in the configuration file and env vars
+type config struct {
+	protection terminate.Protection
+	// auditLog the path to the audit log (empty if not set)
+	auditLog string
+}
+
+// loadConfig returns the settings in the given configuration file (if it exists), ie, the `protection.contexts`,
+// `protection.namespaces` and `protection.finalizers` lists of patterns, and the `audit.path` to the audit log.
+// The settings can also be given (or overridden) with the `KUBECTL_TERMINATE_PROTECTION_CONTEXTS`, `KUBECTL_TERMINATE_PROTECTION_NAMESPACES`,
+// `KUBECTL_TERMINATE_PROTECTION_FINALIZERS` (with comma-separated values) and `KUBECTL_TERMINATE_AUDIT_PATH` env vars
+func loadConfig(path string) (config, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetEnvPrefix("KUBECTL_TERMINATE")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	if err := v.ReadInConfig(); err != nil && !os.IsNotExist(err) {
+		return config{}, fmt.Errorf("error while loading configuration file %s: %w", path, err)
+	}
+	return config{
+		protection: terminate.Protection{
+			Contexts:   stringSlice(v, "protection.contexts"),
+			Namespaces: stringSlice(v, "protection.namespaces"),
+			Finalizers: stringSlice(v, "protection.finalizers"),
+		},
+		auditLog: v.GetString("audit.path"),
+	}, nil
+}
+
+// stringSlice returns the values of the given setting, which may be a list or a comma-separated string (eg: in an env var)
+func stringSlice(v *viper.Viper, key string) []string {
+	values := []string{}
+	for _, value := range v.GetStringSlice(key) {
+		for _, s := range strings.Split(value, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
+
+// auditLogPath returns the path to the audit log, ie, the value of the `--audit-log` flag if it was set,
+// or the path in the configuration, or `~/.kube/terminate/audit.log` by default
+func auditLogPath(flag string, flagChanged bool, cfg config) string {
+	switch {
+	case flagChanged:
+		return flag
+	case cfg.auditLog != "":
+		return cfg.auditLog
+	default:
+		return defaultAuditLog()
+	}
+}
+
+// defaultAuditLog returns the default path to the audit log
+func defaultAuditLog() string {
+	return filepath.Join(homedir.HomeDir(), ".kube", "terminate", "audit.log")
+}
+
+// protectionHint adds a hint about the `--override-protection` flag to the given error, if the resources are protected
+func protectionHint(err error) error {
+	if terminate.IsProtectedError(err) {
+		return fmt.Errorf("%v (use --override-protection to bypass the protection)", err)
+	}
+	return err
+}
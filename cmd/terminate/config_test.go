@@ -0,0 +1,94 @@
+package terminate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/terminate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+
+	// given
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yaml")
+	writeManifest(t, path, `protection:
+  contexts:
+  - prod-*
+  namespaces:
+  - kube-system
+  - openshift-*
+  finalizers:
+  - kubernetes.io/pv-protection
+audit:
+  path: /var/log/kubectl-terminate/audit.log
+`)
+
+	t.Run("from file", func(t *testing.T) {
+		// when
+		cfg, err := loadConfig(path)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, terminate.Protection{
+			Contexts:   []string{"prod-*"},
+			Namespaces: []string{"kube-system", "openshift-*"},
+			Finalizers: []string{"kubernetes.io/pv-protection"},
+		}, cfg.protection)
+		assert.Equal(t, "/var/log/kubectl-terminate/audit.log", cfg.auditLog)
+	})
+
+	t.Run("from env vars", func(t *testing.T) {
+		// given
+		os.Setenv("KUBECTL_TERMINATE_PROTECTION_NAMESPACES", "kube-*, default")
+		defer os.Unsetenv("KUBECTL_TERMINATE_PROTECTION_NAMESPACES")
+		os.Setenv("KUBECTL_TERMINATE_AUDIT_PATH", "/tmp/audit.log")
+		defer os.Unsetenv("KUBECTL_TERMINATE_AUDIT_PATH")
+		// when
+		cfg, err := loadConfig(path)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, terminate.Protection{
+			Contexts:   []string{"prod-*"},
+			Namespaces: []string{"kube-*", "default"},
+			Finalizers: []string{"kubernetes.io/pv-protection"},
+		}, cfg.protection)
+		assert.Equal(t, "/tmp/audit.log", cfg.auditLog)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		// when
+		cfg, err := loadConfig(filepath.Join(dir, "unknown.yaml"))
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, terminate.Protection{
+			Contexts:   []string{},
+			Namespaces: []string{},
+			Finalizers: []string{},
+		}, cfg.protection)
+		assert.Empty(t, cfg.auditLog)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		// given
+		invalid := filepath.Join(dir, "invalid.yaml")
+		writeManifest(t, invalid, "protection: [")
+		// when
+		_, err := loadConfig(invalid)
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error while loading configuration file "+invalid)
+	})
+}
+
+func TestAuditLogPath(t *testing.T) {
+	assert.Equal(t, "/tmp/flag.log", auditLogPath("/tmp/flag.log", true, config{auditLog: "/tmp/config.log"}))
+	assert.Equal(t, "/tmp/config.log", auditLogPath(defaultAuditLog(), false, config{auditLog: "/tmp/config.log"}))
+	assert.Equal(t, defaultAuditLog(), auditLogPath(defaultAuditLog(), false, config{}))
+}
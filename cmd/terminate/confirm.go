@@ -0,0 +1,106 @@
+package terminate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/terminate"
+
+	"github.com/mattn/go-isatty"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// isTerminal returns 'true' if the given output is a terminal
+func isTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	return ok && (isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()))
+}
+
+// confirmInteractively returns a func which asks the user to confirm the termination of each resource in turn.
+// The answers are `y` (terminate this resource), `n` (skip this resource), `all` (terminate this resource and all the remaining ones)
+// and `quit` (skip this resource and all the remaining ones). The remaining resources are also skipped if the input is closed.
+func confirmInteractively(in io.Reader, out io.Writer, now time.Time) terminate.ConfirmFunc {
+	return func(reviews []terminate.Review) ([]bool, error) {
+		confirmed := make([]bool, len(reviews))
+		scanner := bufio.NewScanner(in)
+		for i, r := range reviews {
+			fmt.Fprintln(out, describeReview(r, now)) // nolint: errcheck
+			switch askConfirmation(scanner, out) {
+			case "y":
+				confirmed[i] = true
+			case "n":
+				confirmed[i] = false
+			case "all":
+				for j := i; j < len(confirmed); j++ {
+					confirmed[j] = true
+				}
+				return confirmed, nil
+			default: // quit
+				return confirmed, nil
+			}
+		}
+		return confirmed, nil
+	}
+}
+
+// askConfirmation asks the user for an answer until it is valid, and returns `y`, `n`, `all` or `quit`
+// (also when the input is closed)
+func askConfirmation(scanner *bufio.Scanner, out io.Writer) string {
+	for {
+		fmt.Fprint(out, "terminate? [y/n/all/quit]: ") // nolint: errcheck
+		if !scanner.Scan() {
+			fmt.Fprintln(out) // nolint: errcheck
+			return "quit"
+		}
+		switch answer := strings.ToLower(strings.TrimSpace(scanner.Text())); answer {
+		case "y", "yes":
+			return "y"
+		case "n", "no":
+			return "n"
+		case "a", "all":
+			return "all"
+		case "q", "quit":
+			return "quit"
+		default:
+			fmt.Fprintf(out, "invalid answer: '%s'\n", answer) // nolint: errcheck
+		}
+	}
+}
+
+// describeReview returns the description of the given resource, eg:
+// `pod "cookie" in namespace "default" (terminating for 10m), finalizers to remove: cheesecake`
+func describeReview(r terminate.Review, now time.Time) string {
+	description := fmt.Sprintf("%s \"%s\"", r.Kind, r.Name)
+	if r.Namespace != "" {
+		description += fmt.Sprintf(" in namespace \"%s\"", r.Namespace)
+	}
+	if r.DeletionTimestamp != nil {
+		description += fmt.Sprintf(" (terminating for %s)", duration.HumanDuration(now.Sub(r.DeletionTimestamp.Time)))
+	} else {
+		description += " (not being deleted)"
+	}
+	finalizers := "none"
+	if len(r.Finalizers) > 0 {
+		finalizers = strings.Join(r.Finalizers, ", ")
+	}
+	return description + ", finalizers to remove: " + finalizers
+}
+
+// requireConfirmationFlag returns a func which confirms the termination of all the resources,
+// unless there are more than `threshold` resources, in which case the `--yes` flag is required
+func requireConfirmationFlag(threshold int) terminate.ConfirmFunc {
+	return func(reviews []terminate.Review) ([]bool, error) {
+		if len(reviews) > threshold {
+			return nil, fmt.Errorf("%d resources selected for termination (more than %d): use --yes to confirm", len(reviews), threshold)
+		}
+		confirmed := make([]bool, len(reviews))
+		for i := range confirmed {
+			confirmed[i] = true
+		}
+		return confirmed, nil
+	}
+}
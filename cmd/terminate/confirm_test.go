@@ -0,0 +1,104 @@
+package terminate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/terminate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConfirmInteractively(t *testing.T) {
+
+	// given
+	deletionTimestamp := metav1.Date(2020, time.March, 1, 12, 0, 0, 0, time.UTC)
+	now := deletionTimestamp.Add(10 * time.Minute)
+	reviews := []terminate.Review{
+		{
+			Kind:              "pod",
+			Namespace:         "default",
+			Name:              "cookie",
+			DeletionTimestamp: &deletionTimestamp,
+			Finalizers:        []string{"cheesecake", "example.com/protection"},
+		},
+		{
+			Kind:              "namespace",
+			Name:              "pasta",
+			DeletionTimestamp: &deletionTimestamp,
+			Finalizers:        []string{"kubernetes"},
+		},
+		{
+			Kind:      "pod",
+			Namespace: "default",
+			Name:      "fudge",
+		},
+	}
+
+	t.Run("yes and no", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		// when
+		confirmed, err := confirmInteractively(strings.NewReader("y\nmaybe\nno\nY\n"), out, now)(reviews)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []bool{true, false, true}, confirmed)
+		assert.Equal(t, `pod "cookie" in namespace "default" (terminating for 10m), finalizers to remove: cheesecake, example.com/protection
+terminate? [y/n/all/quit]: namespace "pasta" (terminating for 10m), finalizers to remove: kubernetes
+terminate? [y/n/all/quit]: invalid answer: 'maybe'
+terminate? [y/n/all/quit]: pod "fudge" in namespace "default" (not being deleted), finalizers to remove: none
+terminate? [y/n/all/quit]: `, out.String())
+	})
+
+	t.Run("all", func(t *testing.T) {
+		// when
+		confirmed, err := confirmInteractively(strings.NewReader("n\nall\n"), new(bytes.Buffer), now)(reviews)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []bool{false, true, true}, confirmed)
+	})
+
+	t.Run("quit", func(t *testing.T) {
+		// when
+		confirmed, err := confirmInteractively(strings.NewReader("y\nq\n"), new(bytes.Buffer), now)(reviews)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []bool{true, false, false}, confirmed)
+	})
+
+	t.Run("input closed", func(t *testing.T) {
+		// when
+		confirmed, err := confirmInteractively(strings.NewReader("y\n"), new(bytes.Buffer), now)(reviews)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []bool{true, false, false}, confirmed)
+	})
+}
+
+func TestRequireConfirmationFlag(t *testing.T) {
+
+	// given
+	reviews := []terminate.Review{
+		{Kind: "pod", Name: "cookie"},
+		{Kind: "pod", Name: "cookie2"},
+	}
+
+	t.Run("below threshold", func(t *testing.T) {
+		// when
+		confirmed, err := requireConfirmationFlag(2)(reviews)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []bool{true, true}, confirmed)
+	})
+
+	t.Run("above threshold", func(t *testing.T) {
+		// when
+		_, err := requireConfirmationFlag(1)(reviews)
+		// then
+		require.EqualError(t, err, "2 resources selected for termination (more than 1): use --yes to confirm")
+	})
+}
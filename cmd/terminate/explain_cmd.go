@@ -0,0 +1,43 @@
+package terminate
+
+import (
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+	"github.com/xcoulon/kubectl-terminate/pkg/terminate"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newExplainCommand(flags *globalFlags) *cobra.Command {
+
+	var labelSelector string
+	var fieldSelector string
+
+	cmd := &cobra.Command{
+		Use:           "explain (TYPE NAME | TYPE/NAME | TYPE -l SELECTOR | TYPE --field-selector SELECTOR)",
+		Short:         "explains why the given resource is stuck, without modifying it",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		Args:          cobra.MinimumNArgs(1), // can explain mulitiple resources at once
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log := logger.NewLogger(cmd.OutOrStdout(), flags.loglevel)
+			kubeconfig, err := flags.kubeConfig(log)
+			if err != nil {
+				return err
+			}
+			// deal with resource kinds/names
+			resources, err := parseResources(args, flags.namespace(), labelSelector, fieldSelector)
+			if err != nil {
+				return err
+			}
+			if _, err := terminate.Explain(resources, kubeconfig, log); err != nil {
+				return errors.Cause(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&labelSelector, "selector", "l", "", "(optional) selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	cmd.Flags().StringVarP(&fieldSelector, "field-selector", "", "", "(optional) selector (field query) to filter on, supports '=', '==', and '!='.(e.g. --field-selector key1=value1,key2=value2)")
+
+	return cmd
+}
@@ -0,0 +1,84 @@
+package terminate_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/xcoulon/kubectl-terminate/cmd/terminate"
+	"github.com/xcoulon/kubectl-terminate/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainCmd(t *testing.T) {
+
+	// given
+	server := test.NewServer(t)
+	defer server.Close()
+	_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+	defer os.Remove(kubeconfig.Name())
+	_, resetHome := setTempHome(t)
+	defer resetHome()
+
+	t.Run("ok", func(t *testing.T) {
+
+		t.Run("namespace with remaining resources", func(t *testing.T) {
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "explain", "--kubeconfig="+kubeconfig.Name(), "namespace/pasta")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, `namespace "pasta"
+  deletion timestamp: 2020-03-01T12:00:00Z
+  finalizers: none
+  spec finalizers: kubernetes
+  owners: none
+  conditions:
+    - NamespaceFinalizersRemaining=True: Some content in the namespace has finalizers remaining: cheesecake in 1 resource instances
+  remaining resources with finalizers:
+    - pods/penne: cheesecake
+  suggested commands:
+    kubectl terminate -n pasta pods penne
+    kubectl terminate namespaces/pasta
+`, out)
+		})
+
+		t.Run("pod with missing owner", func(t *testing.T) {
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "explain", "--kubeconfig="+kubeconfig.Name(), "-n", "pasta", "pod", "penne")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, `pod "penne" in namespace "pasta"
+  deletion timestamp: 2020-03-01T12:00:00Z
+  finalizers: cheesecake
+  owners:
+    - Deployment/rigatoni (not found, blocking owner deletion)
+  suggested commands:
+    kubectl terminate -n pasta pods/penne
+`, out)
+		})
+
+		t.Run("deployment not being deleted", func(t *testing.T) {
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "explain", "--kubeconfig="+kubeconfig.Name(), "deploy/latte")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, `deploy "latte" in namespace "default"
+  deletion timestamp: none (not being deleted)
+  finalizers: none
+  owners: none
+`, out)
+		})
+	})
+
+	t.Run("failures", func(t *testing.T) {
+
+		t.Run("unknown resource", func(t *testing.T) {
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "explain", "--kubeconfig="+kubeconfig.Name(), "pod/unknown")
+			// then
+			require.Error(t, err)
+			assert.Equal(t, "the server could not find the requested resource", err.Error())
+		})
+	})
+}
@@ -0,0 +1,91 @@
+package terminate
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// kubeConfig returns the client config resulting from the standard kubectl loading rules and precedence, i.e.:
+// the `--kubeconfig` flag, or the files listed in the `$KUBECONFIG` env var (merged), or the `~/.kube/config` file,
+// or the in-cluster config when running in a pod, along with the connection flags (`--context`, `--server`, `--as`, etc.)
+func (f *globalFlags) kubeConfig(log logger.Logger) (clientcmd.ClientConfig, error) {
+	kubeconfig := f.configFlags.ToRawKubeConfigLoader()
+	config, err := kubeconfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error while loading kubeconfig: %w", err)
+	}
+	raw, err := kubeconfig.RawConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error while loading kubeconfig: %w", err)
+	}
+	logContextOrigins(raw, kubeconfig.ConfigAccess().GetLoadingPrecedence(), log)
+	log.Debug("using server %s", config.Host)
+	return kubeconfig, nil
+}
+
+// logContextOrigins logs the file from which each context was loaded, in a deterministic order
+func logContextOrigins(raw clientcmdapi.Config, precedence []string, log logger.Logger) {
+	if len(raw.Contexts) == 0 {
+		log.Debug("no context found in %s, using in-cluster configuration (if available)", strings.Join(precedence, string(filepath.ListSeparator)))
+		return
+	}
+	names := make([]string, 0, len(raw.Contexts))
+	for name := range raw.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		log.Debug("context '%s' loaded from %s", name, raw.Contexts[name].LocationOfOrigin)
+	}
+}
+
+// namespace returns the value of the `--namespace` flag
+func (f *globalFlags) namespace() string {
+	if f.configFlags.Namespace == nil {
+		return ""
+	}
+	return *f.configFlags.Namespace
+}
+
+// currentContext returns the name of the kubeconfig context in use, ie, the value of the `--context` flag,
+// or the current context of the kubeconfig (empty when using the in-cluster configuration)
+func (f *globalFlags) currentContext() (string, error) {
+	if f.configFlags.Context != nil && *f.configFlags.Context != "" {
+		return *f.configFlags.Context, nil
+	}
+	raw, err := f.configFlags.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return "", fmt.Errorf("error while loading kubeconfig: %w", err)
+	}
+	return raw.CurrentContext, nil
+}
+
+// kubernetesUser returns the name of the Kubernetes user as whom the requests are sent, ie, the value of the `--as` flag,
+// or the value of the `--user` flag, or the user of the kubeconfig context in use (empty when using the in-cluster configuration)
+func (f *globalFlags) kubernetesUser() (string, error) {
+	if f.configFlags.Impersonate != nil && *f.configFlags.Impersonate != "" {
+		return *f.configFlags.Impersonate, nil
+	}
+	if f.configFlags.AuthInfoName != nil && *f.configFlags.AuthInfoName != "" {
+		return *f.configFlags.AuthInfoName, nil
+	}
+	context, err := f.currentContext()
+	if err != nil {
+		return "", err
+	}
+	raw, err := f.configFlags.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return "", fmt.Errorf("error while loading kubeconfig: %w", err)
+	}
+	if c, found := raw.Contexts[context]; found {
+		return c.AuthInfo, nil
+	}
+	return "", nil
+}
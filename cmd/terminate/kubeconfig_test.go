@@ -0,0 +1,101 @@
+package terminate
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+	"github.com/xcoulon/kubectl-terminate/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestLogContextOrigins(t *testing.T) {
+
+	t.Run("contexts in multiple files", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		raw := clientcmdapi.Config{
+			Contexts: map[string]*clientcmdapi.Context{
+				"prod": {LocationOfOrigin: "/home/user/.kube/prod.yaml"},
+				"dev":  {LocationOfOrigin: "/home/user/.kube/config"},
+			},
+		}
+		// when
+		logContextOrigins(raw, []string{"/home/user/.kube/config", "/home/user/.kube/prod.yaml"}, logger.NewLogger(out, 1))
+		// then
+		assert.Equal(t, "context 'dev' loaded from /home/user/.kube/config\ncontext 'prod' loaded from /home/user/.kube/prod.yaml\n", out.String())
+	})
+
+	t.Run("no context", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		// when
+		logContextOrigins(clientcmdapi.Config{}, []string{"/home/user/.kube/config"}, logger.NewLogger(out, 1))
+		// then
+		assert.Equal(t, "no context found in /home/user/.kube/config, using in-cluster configuration (if available)\n", out.String())
+	})
+}
+
+func TestKubernetesUser(t *testing.T) {
+
+	// given
+	_, kubeconfig := test.NewKubeConfigFile(t, "http://127.0.0.1:1")
+	defer os.Remove(kubeconfig.Name())
+	newFlags := func() *globalFlags {
+		f := &globalFlags{
+			configFlags: genericclioptions.NewConfigFlags(false),
+		}
+		path := kubeconfig.Name()
+		f.configFlags.KubeConfig = &path
+		return f
+	}
+
+	t.Run("user of the current context", func(t *testing.T) {
+		// when
+		user, err := newFlags().kubernetesUser()
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "test", user)
+	})
+
+	t.Run("context without user", func(t *testing.T) {
+		// given
+		f := newFlags()
+		context := "unreachable"
+		f.configFlags.Context = &context
+		// when
+		user, err := f.kubernetesUser()
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, user)
+	})
+
+	t.Run("user flag", func(t *testing.T) {
+		// given
+		f := newFlags()
+		authInfo := "admin"
+		f.configFlags.AuthInfoName = &authInfo
+		// when
+		user, err := f.kubernetesUser()
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "admin", user)
+	})
+
+	t.Run("impersonated user", func(t *testing.T) {
+		// given
+		f := newFlags()
+		impersonate := "jdoe@example.com"
+		f.configFlags.Impersonate = &impersonate
+		// when
+		user, err := f.kubernetesUser()
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "jdoe@example.com", user)
+	})
+}
@@ -0,0 +1,66 @@
+package terminate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+	"github.com/xcoulon/kubectl-terminate/pkg/terminate"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newListCommand(flags *globalFlags) *cobra.Command {
+
+	var allNamespaces bool
+	var olderThan time.Duration
+	var output string
+
+	cmd := &cobra.Command{
+		Use:           "list [--older-than DURATION] [-A]",
+		Short:         "lists the resources which are stuck in deletion, without modifying them",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outputFormat, err := getOutputFormat(output)
+			if err != nil {
+				return err
+			}
+			if outputFormat == terminate.OutputName && allNamespaces {
+				// the names do not include the namespace, so they could not be given to `kubectl terminate -f -`
+				return fmt.Errorf(`invalid output format (%v). Cannot be used with --all-namespaces`, output)
+			}
+			if olderThan < 0 {
+				return fmt.Errorf("invalid older-than value (%v). Must be greater than or equal to 0", olderThan)
+			}
+			log := logger.NewLogger(cmd.OutOrStdout(), flags.loglevel)
+			if outputFormat != terminate.OutputNone {
+				// keep the standard output for the results
+				log = logger.NewLogger(cmd.ErrOrStderr(), flags.loglevel)
+			}
+			kubeconfig, err := flags.kubeConfig(log)
+			if err != nil {
+				return err
+			}
+			namespace := ""
+			if !allNamespaces {
+				// the namespace given with `-n`, or the namespace of the current context
+				if namespace, _, err = kubeconfig.Namespace(); err != nil {
+					return err
+				}
+			}
+			resources, err := terminate.List(kubeconfig, namespace, olderThan, log)
+			if err != nil {
+				return errors.Cause(err)
+			}
+			return terminate.PrintStuckResources(cmd.OutOrStdout(), outputFormat, resources, time.Now())
+		},
+	}
+	cmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "(optional) list the resources in all namespaces, including the cluster-scoped resources (eg: namespaces)")
+	cmd.Flags().DurationVarP(&olderThan, "older-than", "", 0, "(optional) only list the resources which are being deleted for longer than this duration (eg: 10m)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "(optional) output format of the resources. One of: json|yaml|name|wide. By default, the resources are grouped by namespace and finalizer.")
+
+	return cmd
+}
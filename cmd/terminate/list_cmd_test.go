@@ -0,0 +1,104 @@
+package terminate_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/xcoulon/kubectl-terminate/cmd/terminate"
+	pkgterminate "github.com/xcoulon/kubectl-terminate/pkg/terminate"
+	"github.com/xcoulon/kubectl-terminate/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCmd(t *testing.T) {
+
+	// given
+	server := test.NewServer(t)
+	defer server.Close()
+	_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+	defer os.Remove(kubeconfig.Name())
+	_, resetHome := setTempHome(t)
+	defer resetHome()
+
+	t.Run("ok", func(t *testing.T) {
+
+		t.Run("in namespace", func(t *testing.T) {
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "list", "--kubeconfig="+kubeconfig.Name(), "-n", "pasta", "--older-than", "10m")
+			// then
+			require.NoError(t, err)
+			assert.Regexp(t, `^namespace "pasta":
+  finalizer "cheesecake":
+    pod/penne \(deleted \w+ ago\)
+$`, out)
+		})
+
+		t.Run("in current namespace with types which cannot be listed", func(t *testing.T) {
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "list", "--kubeconfig="+kubeconfig.Name())
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, `WARNING: unable to list 'customtypes.customdomain': the server could not find the requested resource
+WARNING: unable to list 'deployments.apps': the server could not find the requested resource
+No resources found
+`, out)
+		})
+
+		t.Run("in all namespaces", func(t *testing.T) {
+			// when
+			stdout, _, err := executeCommandWithStderr(terminate.NewCommand(), "list", "--kubeconfig="+kubeconfig.Name(), "-A", "-o", "json")
+			// then
+			require.NoError(t, err)
+			records := []pkgterminate.StuckRecord{}
+			err = json.Unmarshal([]byte(stdout), &records)
+			require.NoError(t, err)
+			names := []string{}
+			for _, r := range records {
+				names = append(names, r.Namespace+"/"+r.Resource+"/"+r.Name)
+			}
+			assert.Equal(t, []string{"/namespaces/pasta", "default/pods/cookie", "default/pods/fudge", "pasta/pods/penne"}, names)
+		})
+
+		t.Run("not deleted for long enough", func(t *testing.T) {
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "list", "--kubeconfig="+kubeconfig.Name(), "-A", "--older-than", "876000h")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "No resources found\n", out)
+		})
+	})
+
+	t.Run("failures", func(t *testing.T) {
+
+		t.Run("invalid older-than", func(t *testing.T) {
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "list", "--kubeconfig="+kubeconfig.Name(), "--older-than", "-1m")
+			// then
+			require.EqualError(t, err, "invalid older-than value (-1m0s). Must be greater than or equal to 0")
+		})
+
+		t.Run("invalid output format", func(t *testing.T) {
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "list", "--kubeconfig="+kubeconfig.Name(), "-o", "table")
+			// then
+			require.EqualError(t, err, `invalid output format (table). Must be "json", "yaml", "name", or "wide"`)
+		})
+
+		t.Run("name output format in all namespaces", func(t *testing.T) {
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "list", "--kubeconfig="+kubeconfig.Name(), "-A", "-o", "name")
+			// then
+			require.EqualError(t, err, `invalid output format (name). Cannot be used with --all-namespaces`)
+		})
+
+		t.Run("with args", func(t *testing.T) {
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "list", "--kubeconfig="+kubeconfig.Name(), "pods")
+			// then
+			require.Error(t, err)
+		})
+	})
+}
@@ -0,0 +1,178 @@
+package terminate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/terminate"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// readManifests returns the metadata of the resources to terminate, given the manifests in the given files and directories
+// (and their subdirectories if `recursive` is true), or in the standard input if the filename is `-`.
+// The manifests contain YAML or JSON documents (including lists), or `TYPE/NAME` lines (eg: the output of `kubectl get -o name`).
+// The resources with no namespace in their manifest are in the given namespace (if not empty).
+func readManifests(filenames []string, recursive bool, stdin io.Reader, namespace string) ([]terminate.ResourceMetadata, error) {
+	resources := []terminate.ResourceMetadata{}
+	for _, filename := range filenames {
+		if filename == "-" {
+			r, err := readManifest(stdin, "STDIN", namespace)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, r...)
+			continue
+		}
+		paths, err := manifestPaths(filename, recursive)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range paths {
+			f, err := os.Open(p)
+			if err != nil {
+				return nil, err
+			}
+			r, err := readManifest(f, p, namespace)
+			f.Close()
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, r...)
+		}
+	}
+	return resources, nil
+}
+
+// readsStdin returns 'true' if the manifests are read from the standard input
+func readsStdin(filenames []string) bool {
+	for _, filename := range filenames {
+		if filename == "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// manifestPaths returns the given path if it is a file, or the paths to the `.json`, `.yaml` and `.yml` files
+// in the given directory (and its subdirectories if `recursive` is true), in lexical order
+func manifestPaths(path string, recursive bool) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	paths := []string{}
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if p != path && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch filepath.Ext(p) {
+		case ".json", ".yaml", ".yml":
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// readManifest returns the metadata of the resources in the given manifest
+func readManifest(r io.Reader, source, namespace string) ([]terminate.ResourceMetadata, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if names := resourceNames(data); len(names) > 0 {
+		return parseResources(names, namespace, "", "")
+	}
+	resources := []terminate.ResourceMetadata{}
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err == io.EOF {
+			return resources, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("error while reading %s: %w", source, err)
+		}
+		if len(obj.Object) == 0 {
+			continue // empty document
+		}
+		if !obj.IsList() {
+			m, err := manifestResource(obj, source, namespace)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, m)
+			continue
+		}
+		err := obj.EachListItem(func(item runtime.Object) error {
+			m, err := manifestResource(item.(*unstructured.Unstructured), source, namespace)
+			if err != nil {
+				return err
+			}
+			resources = append(resources, m)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// resourceNameRegexp matches the `TYPE/NAME` lines, eg: `pod/cookie` or `deployment.apps/latte`
+var resourceNameRegexp = regexp.MustCompile(`^[^\s:/{}\[\]"]+/[^\s:/{}\[\]"]+$`)
+
+// resourceNames returns the non-empty lines of the given data if all of them are in the `TYPE/NAME` form, or nil otherwise
+func resourceNames(data []byte) []string {
+	names := []string{}
+	for _, l := range strings.Split(string(data), "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+		if !resourceNameRegexp.MatchString(l) {
+			return nil
+		}
+		names = append(names, l)
+	}
+	return names
+}
+
+// manifestResource returns the metadata of the given resource from a manifest
+func manifestResource(obj *unstructured.Unstructured, source, namespace string) (terminate.ResourceMetadata, error) {
+	if obj.GetAPIVersion() == "" || obj.GetKind() == "" {
+		return terminate.ResourceMetadata{}, fmt.Errorf("missing apiVersion or kind in a resource in %s", source)
+	}
+	if obj.GetName() == "" {
+		return terminate.ResourceMetadata{}, fmt.Errorf("missing name in a resource of kind '%s' in %s", obj.GetKind(), source)
+	}
+	ns := obj.GetNamespace()
+	if ns != "" && namespace != "" && ns != namespace {
+		return terminate.ResourceMetadata{}, fmt.Errorf("the namespace from the provided object \"%s\" does not match the namespace \"%s\". You must pass '--namespace=%s' to perform this operation", ns, namespace, ns)
+	}
+	if ns == "" {
+		ns = namespace
+	}
+	return terminate.ResourceMetadata{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  ns,
+		Name:       obj.GetName(),
+	}, nil
+}
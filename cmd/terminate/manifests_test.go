@@ -0,0 +1,155 @@
+package terminate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/terminate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadManifests(t *testing.T) {
+
+	// given
+	dir, err := ioutil.TempDir("", "manifests")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeManifest(t, filepath.Join(dir, "pods.yaml"), `apiVersion: v1
+kind: Pod
+metadata:
+  name: cookie
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: cookie2
+  namespace: dessert
+`)
+	writeManifest(t, filepath.Join(dir, "deployment.json"), `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "latte"}}`)
+	writeManifest(t, filepath.Join(dir, "README.md"), `not a manifest`)
+	writeManifest(t, filepath.Join(dir, "nested", "list.yml"), `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Namespace
+  metadata:
+    name: pasta
+- apiVersion: customdomain/v1beta1
+  kind: CustomType
+  metadata:
+    name: cookie
+`)
+	cookie := terminate.ResourceMetadata{APIVersion: "v1", Kind: "Pod", Name: "cookie"}
+	cookie2 := terminate.ResourceMetadata{APIVersion: "v1", Kind: "Pod", Namespace: "dessert", Name: "cookie2"}
+	latte := terminate.ResourceMetadata{APIVersion: "apps/v1", Kind: "Deployment", Name: "latte"}
+	pasta := terminate.ResourceMetadata{APIVersion: "v1", Kind: "Namespace", Name: "pasta"}
+	customCookie := terminate.ResourceMetadata{APIVersion: "customdomain/v1beta1", Kind: "CustomType", Name: "cookie"}
+
+	t.Run("ok", func(t *testing.T) {
+
+		t.Run("single file", func(t *testing.T) {
+			// when
+			resources, err := readManifests([]string{filepath.Join(dir, "pods.yaml")}, false, nil, "")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, []terminate.ResourceMetadata{cookie, cookie2}, resources)
+		})
+
+		t.Run("directory", func(t *testing.T) {
+			// when
+			resources, err := readManifests([]string{dir}, false, nil, "")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, []terminate.ResourceMetadata{latte, cookie, cookie2}, resources)
+		})
+
+		t.Run("directory with recursion", func(t *testing.T) {
+			// when
+			resources, err := readManifests([]string{dir}, true, nil, "")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, []terminate.ResourceMetadata{latte, pasta, customCookie, cookie, cookie2}, resources)
+		})
+
+		t.Run("with namespace", func(t *testing.T) {
+			// when
+			resources, err := readManifests([]string{filepath.Join(dir, "deployment.json")}, false, nil, "dessert")
+			// then
+			require.NoError(t, err)
+			require.Len(t, resources, 1)
+			assert.Equal(t, "dessert", resources[0].Namespace)
+		})
+
+		t.Run("stdin with manifest", func(t *testing.T) {
+			// given
+			stdin := strings.NewReader(`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "latte"}}`)
+			// when
+			resources, err := readManifests([]string{"-"}, false, stdin, "")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, []terminate.ResourceMetadata{latte}, resources)
+		})
+
+		t.Run("stdin with names", func(t *testing.T) {
+			// given
+			stdin := strings.NewReader("pod/cookie\ndeployment.apps/latte\n\n")
+			// when
+			resources, err := readManifests([]string{"-"}, false, stdin, "dessert")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, []terminate.ResourceMetadata{
+				{Kind: "pod", Namespace: "dessert", Name: "cookie"},
+				{Kind: "deployment.apps", Namespace: "dessert", Name: "latte"},
+			}, resources)
+		})
+	})
+
+	t.Run("failures", func(t *testing.T) {
+
+		t.Run("missing file", func(t *testing.T) {
+			// when
+			_, err := readManifests([]string{filepath.Join(dir, "unknown.yaml")}, false, nil, "")
+			// then
+			require.Error(t, err)
+			assert.True(t, os.IsNotExist(err))
+		})
+
+		t.Run("namespace mismatch", func(t *testing.T) {
+			// when
+			_, err := readManifests([]string{filepath.Join(dir, "pods.yaml")}, false, nil, "default")
+			// then
+			require.EqualError(t, err, `the namespace from the provided object "dessert" does not match the namespace "default". You must pass '--namespace=dessert' to perform this operation`)
+		})
+
+		t.Run("missing name", func(t *testing.T) {
+			// given
+			stdin := strings.NewReader("apiVersion: v1\nkind: Pod\nmetadata:\n  generateName: cookie-\n")
+			// when
+			_, err := readManifests([]string{"-"}, false, stdin, "")
+			// then
+			require.EqualError(t, err, "missing name in a resource of kind 'Pod' in STDIN")
+		})
+
+		t.Run("invalid manifest", func(t *testing.T) {
+			// given
+			stdin := strings.NewReader("pod cookie\n")
+			// when
+			_, err := readManifests([]string{"-"}, false, stdin, "")
+			// then
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "error while reading STDIN")
+		})
+	})
+}
+
+func writeManifest(t *testing.T, path, content string) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(path, []byte(content), 0644)
+	require.NoError(t, err)
+}
@@ -0,0 +1,39 @@
+package terminate
+
+import (
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+	"github.com/xcoulon/kubectl-terminate/pkg/terminate"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newRestoreCommand(flags *globalFlags) *cobra.Command {
+
+	cmd := &cobra.Command{
+		Use:           "restore BACKUP_DIR [TYPE NAME... | TYPE/NAME...]",
+		Short:         "re-creates the resources saved in the given backup directory (or file), optionally only the given ones",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		Args:          cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log := logger.NewLogger(cmd.OutOrStdout(), flags.loglevel)
+			kubeconfig, err := flags.kubeConfig(log)
+			if err != nil {
+				return err
+			}
+			// deal with resource kinds/names
+			filters := []terminate.ResourceMetadata{}
+			if len(args) > 1 {
+				if filters, err = parseResources(args[1:], flags.namespace(), "", ""); err != nil {
+					return err
+				}
+			}
+			if _, err := terminate.Restore(args[0], flags.namespace(), filters, kubeconfig, log); err != nil {
+				return errors.Cause(err)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
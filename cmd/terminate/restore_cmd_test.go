@@ -0,0 +1,54 @@
+package terminate_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/xcoulon/kubectl-terminate/cmd/terminate"
+	"github.com/xcoulon/kubectl-terminate/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreCmd(t *testing.T) {
+
+	// given
+	server := test.NewServer(t)
+	defer server.Close()
+	_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+	defer os.Remove(kubeconfig.Name())
+	_, resetHome := setTempHome(t)
+	defer resetHome()
+	dir, err := ioutil.TempDir("", "backups")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	// terminate (and backup) a pod first
+	_, err = executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--backup-dir="+dir, "pod/cookie")
+	require.NoError(t, err)
+
+	t.Run("all resources", func(t *testing.T) {
+		// when
+		out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "restore", dir)
+		// then
+		require.NoError(t, err)
+		// the fake server still has the `cookie` pod
+		assert.Equal(t, "pod \"cookie\" skipped (already exists)\n", out)
+	})
+
+	t.Run("selected resources", func(t *testing.T) {
+		// when
+		out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "restore", dir, "pod", "cookie2")
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "No resources found\n", out)
+	})
+
+	t.Run("missing backup dir", func(t *testing.T) {
+		// when
+		_, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "restore")
+		// then
+		require.Error(t, err)
+	})
+}
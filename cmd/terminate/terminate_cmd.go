@@ -5,107 +5,325 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/xcoulon/kubectl-terminate/pkg/logger"
 	"github.com/xcoulon/kubectl-terminate/pkg/terminate"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/util/homedir"
 )
 
-func InitAndExecute() {
+const (
+	// exitCodeError the exit code when the command failed
+	exitCodeError = 1
+	// exitCodeTerminationFailed the exit code when the termination of some resources failed (with `--continue-on-error`)
+	exitCodeTerminationFailed = 2
+)
+
+// version the version of the tool, which is recorded in the audit log
+var version = "unknown"
+
+// InitAndExecute executes the command, with the given version of the tool
+func InitAndExecute(v string) {
+	version = v
 	if err := NewCommand().Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, err)
+		if terminate.IsTerminationFailedError(err) {
+			os.Exit(exitCodeTerminationFailed)
+		}
+		os.Exit(exitCodeError)
 	}
 }
 
-func initConfig() {
-	viper.AutomaticEnv()
+// globalFlags the flags shared by the `terminate` command and its subcommands
+type globalFlags struct {
+	configFlags *genericclioptions.ConfigFlags
+	loglevel    int
 }
 
 func NewCommand() *cobra.Command {
 
-	var kubeconfig string
-	var namespace string
-	var loglevel int
+	flags := &globalFlags{
+		configFlags: genericclioptions.NewConfigFlags(false),
+	}
+	var filenames []string
+	var recursive bool
+	var labelSelector string
+	var fieldSelector string
+	var forceLive bool
+	var dryRun string
+	var finalizers []string
+	var keepFinalizers []string
+	var cascade string
+	var content bool
+	var gracePeriod int
+	var force bool
+	var parallel int
+	var continueOnError bool
+	var output string
+	var backupDir string
+	var noBackup bool
+	var wait bool
+	var timeout time.Duration
+	var yes bool
+	var confirmThreshold int
+	var overrideProtection bool
+	var auditLog string
+	var recordEvent bool
 
 	cmd := &cobra.Command{
-		Use:           "terminate (TYPE NAME | TYPE/NAME)",
+		Use:           "terminate (TYPE NAME | TYPE/NAME | TYPE -l SELECTOR | TYPE --field-selector SELECTOR | -f FILENAME)",
 		Short:         "removes the finalizers and deletes the given resource",
 		SilenceErrors: true,
 		SilenceUsage:  true,
-		Args:          cobra.MinimumNArgs(1), // can terminate mulitiple resources at once
+		Args:          cobra.ArbitraryArgs, // can terminate mulitiple resources at once (or none if they are in manifests)
 		RunE: func(cmd *cobra.Command, args []string) error {
-			log := logger.NewLogger(cmd.OutOrStdout(), loglevel)
-			// look-up the kubeconfig to use
-			kubeconfigFile, err := getKubeconfigFile(kubeconfig)
+			outputFormat, err := getOutputFormat(output)
+			if err != nil {
+				return err
+			}
+			log := logger.NewLogger(cmd.OutOrStdout(), flags.loglevel)
+			if outputFormat != terminate.OutputNone {
+				// keep the standard output for the results
+				log = logger.NewLogger(cmd.ErrOrStderr(), flags.loglevel)
+			}
+			kubeconfig, err := flags.kubeConfig(log)
 			if err != nil {
-				return fmt.Errorf("error while locating KUBECONFIG: %w", err)
-			}
-			log.Debug("using kubeconfig at %s", kubeconfigFile.Name())
-			// deal with resource kinds/names
-			resources := make([]terminate.ResourceMetadata, 0, len(args))
-			// if the first arg does not contain a `/`, then assume its a kind.
-			// otherwise, split all args
-			if !strings.Contains(args[0], "/") {
-				kind := args[0]
-				// all other args are the resource names (of the same kind)
-				for _, name := range args[1:] {
-					resources = append(resources, terminate.ResourceMetadata{
-						Kind:      kind,
-						Name:      name,
-						Namespace: namespace,
-					})
+				return err
+			}
+			// deal with resource kinds/names, or manifests
+			var resources []terminate.ResourceMetadata
+			switch {
+			case len(filenames) > 0 && (len(args) > 0 || labelSelector != "" || fieldSelector != ""):
+				return fmt.Errorf("resources and selectors cannot be provided when manifests are specified with -f")
+			case len(filenames) > 0:
+				if resources, err = readManifests(filenames, recursive, cmd.InOrStdin(), flags.namespace()); err != nil {
+					return err
+				}
+			case len(args) == 0:
+				return fmt.Errorf("you must specify the type and name of the resources to terminate, or manifests with -f")
+			default:
+				if resources, err = parseResources(args, flags.namespace(), labelSelector, fieldSelector); err != nil {
+					return err
 				}
+			}
+			dryRunStrategy, err := getDryRunStrategy(dryRun)
+			if err != nil {
+				return err
+			}
+			if parallel < 1 {
+				return fmt.Errorf("invalid parallel value (%d). Must be greater than 0", parallel)
+			}
+			propagationPolicy, err := getPropagationPolicy(cascade)
+			if err != nil {
+				return err
+			}
+			if timeout < 0 {
+				return fmt.Errorf("invalid timeout value (%v). Must be greater than or equal to 0", timeout)
+			}
+			opts := terminate.Options{
+				ForceLive:          forceLive,
+				DryRun:             dryRunStrategy,
+				Finalizers:         finalizers,
+				KeepFinalizers:     keepFinalizers,
+				Content:            content,
+				PropagationPolicy:  propagationPolicy,
+				GracePeriodSeconds: getGracePeriod(gracePeriod, force),
+				Parallel:           parallel,
+				ContinueOnError:    continueOnError,
+				Wait:               wait,
+				Timeout:            timeout,
+			}
+			cfg, err := loadConfig(configFile())
+			if err != nil {
+				return err
+			}
+			context, err := flags.currentContext()
+			if err != nil {
+				return err
+			}
+			if overrideProtection {
+				log.Debug("ignoring the protection settings")
 			} else {
-				for _, arg := range args {
-					kindname := strings.Split(arg, "/")
-					if len(kindname) != 2 {
-						return fmt.Errorf("invalid resource name: %s", arg)
-					}
-					kind := kindname[0]
-					name := kindname[1]
-					resources = append(resources, terminate.ResourceMetadata{
-						Kind:      kind,
-						Name:      name,
-						Namespace: namespace,
-					})
+				opts.Protection = cfg.protection
+				if err := opts.Protection.CheckContext(context); err != nil {
+					return protectionHint(err)
 				}
 			}
-			if err := terminate.Terminate(resources, kubeconfigFile, log); err != nil {
-				return errors.Cause(err)
+			if opts.AuditLog, err = newAuditLog(auditLogPath(auditLog, cmd.Flags().Changed("audit-log"), cfg), context, kubeconfig); err != nil {
+				return err
+			}
+			if recordEvent && dryRunStrategy == terminate.DryRunNone {
+				kubernetesUser, err := flags.kubernetesUser()
+				if err != nil {
+					return err
+				}
+				if opts.EventRecorder, err = terminate.NewEventRecorder(kubeconfig, localUser(), kubernetesUser); err != nil {
+					return err
+				}
+			}
+			if confirmThreshold < 0 {
+				return fmt.Errorf("invalid confirm-threshold value (%d). Must be greater than or equal to 0", confirmThreshold)
+			}
+			if !yes && dryRunStrategy == terminate.DryRunNone {
+				// the answers cannot be read from the standard input if it is used for the manifests
+				if isTerminal(cmd.OutOrStdout()) && !readsStdin(filenames) {
+					opts.Confirm = confirmInteractively(cmd.InOrStdin(), log.Output(), time.Now())
+				} else {
+					opts.Confirm = requireConfirmationFlag(confirmThreshold)
+				}
+			}
+			if force {
+				log.Info("warning: Immediate deletion does not wait for confirmation that the running resource has been terminated. The resource may continue to run on the cluster indefinitely.")
+			}
+			if !noBackup {
+				// one backup directory per run
+				opts.BackupDir = filepath.Join(backupDir, time.Now().UTC().Format("20060102T150405Z"))
+				log.Debug("saving the resources in %s before they are modified", opts.BackupDir)
+			}
+			results, err := terminate.Terminate(resources, kubeconfig, opts, log)
+			// also print the results if the termination failed
+			if err := terminate.PrintResults(cmd.OutOrStdout(), outputFormat, results); err != nil {
+				return err
+			}
+			if err != nil {
+				return protectionHint(errors.Cause(err))
 			}
 			return nil
 		},
 	}
-	cmd.Flags().StringVarP(&kubeconfig, "kubeconfig", "", "", "(optional) absolute path to the kubeconfig file")
-	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "(optional) the namespace scope for this CLI request")
-	cmd.Flags().IntVarP(&loglevel, "loglevel", "v", 0, "log level for V logs (set to 1 or higher to display DEBUG messages)")
+	flags.configFlags.AddFlags(cmd.PersistentFlags()) // --kubeconfig, --context, --namespace, --server, --as, etc.
+	cmd.Flags().StringSliceVarP(&filenames, "filename", "f", []string{}, "(optional) file or directory with the manifests of the resources to terminate, or '-' to read them from the standard input. The manifests contain YAML or JSON documents, or TYPE/NAME lines (eg: the output of 'kubectl get -o name'). Can be repeated.")
+	cmd.Flags().BoolVarP(&recursive, "recursive", "R", false, "(optional) process the directories given with -f recursively")
+	cmd.Flags().StringVarP(&labelSelector, "selector", "l", "", "(optional) selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	cmd.Flags().StringVarP(&fieldSelector, "field-selector", "", "", "(optional) selector (field query) to filter on, supports '=', '==', and '!='.(e.g. --field-selector key1=value1,key2=value2)")
+	cmd.Flags().BoolVarP(&forceLive, "force-live", "", false, "(optional) also terminate the resources which are not being deleted (ie, which have no deletion timestamp)")
+	cmd.Flags().StringVarP(&dryRun, "dry-run", "", "none", "(optional) must be \"none\", \"client\", or \"server\". If client strategy, only print the finalizers that would be removed and the requests that would be sent, without sending them. If server strategy, submit server-side requests without persisting the resource.")
+	cmd.Flags().StringArrayVarP(&finalizers, "finalizer", "", []string{}, "(optional) only remove the finalizers matching this pattern (eg: '*.example.com/*'). Can be repeated.")
+	cmd.Flags().StringArrayVarP(&keepFinalizers, "keep-finalizer", "", []string{}, "(optional) remove all finalizers except the ones matching this pattern (eg: 'kubernetes.io/*'). Can be repeated.")
+	cmd.Flags().StringVarP(&cascade, "cascade", "", "background", "(optional) must be \"background\", \"foreground\", or \"orphan\". Selects the deletion cascading strategy for the dependents (e.g. Pods created by a ReplicationController).")
+	cmd.Flags().Lookup("cascade").NoOptDefVal = "background" // `--cascade` without value
+	cmd.Flags().BoolVarP(&content, "content", "", false, "(optional) on a namespace, first terminate all the remaining resources which are being deleted in this namespace")
+	cmd.Flags().IntVarP(&gracePeriod, "grace-period", "", -1, "(optional) period of time in seconds given to the resource to terminate gracefully. Ignored if negative. Set to 1 for immediate shutdown. Can only be set to 0 when --force is true (force deletion).")
+	cmd.Flags().BoolVarP(&force, "force", "", false, "(optional) immediately remove the resources from the API and bypass graceful deletion (eg: pods stuck on an unreachable node)")
+	cmd.Flags().IntVarP(&parallel, "parallel", "", 1, "(optional) the maximum number of resources to terminate concurrently")
+	cmd.Flags().BoolVarP(&continueOnError, "continue-on-error", "", false, fmt.Sprintf("(optional) attempt to terminate all resources even if some of them failed, and exit with code %d if any of them failed", exitCodeTerminationFailed))
+	cmd.Flags().StringVarP(&output, "output", "o", "", "(optional) output format of the results. One of: json|yaml|name|wide. The messages during the termination are then printed on the standard error.")
+	cmd.Flags().StringVarP(&backupDir, "backup-dir", "", filepath.Join(homedir.HomeDir(), ".kube", "terminate", "backups"), "(optional) the directory in which the resources are saved before they are modified (in a subdirectory named after the current time)")
+	cmd.Flags().BoolVarP(&noBackup, "no-backup", "", false, "(optional) do not save the resources before they are modified")
+	cmd.Flags().BoolVarP(&wait, "wait", "", false, "(optional) wait until the resources are actually removed, and report the ones which were blocked again by some finalizers")
+	cmd.Flags().DurationVarP(&timeout, "timeout", "", 5*time.Minute, "(optional) the maximum duration to wait for the removal of each resource with '--wait' (zero means no limit)")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "(optional) terminate the resources without confirmation. Otherwise, the termination of each resource must be confirmed when the standard output is a terminal.")
+	cmd.Flags().IntVarP(&confirmThreshold, "confirm-threshold", "", 10, "(optional) the maximum number of resources which can be terminated without '--yes' when the standard output is not a terminal")
+	cmd.Flags().BoolVarP(&overrideProtection, "override-protection", "", false, fmt.Sprintf("(optional) terminate the resources even if they are protected by the contexts, namespaces or finalizers in %s", configFile()))
+	cmd.Flags().StringVarP(&auditLog, "audit-log", "", defaultAuditLog(), fmt.Sprintf("(optional) the file in which a record is appended for each resource which is modified (overrides the 'audit.path' setting in %s)", configFile()))
+	cmd.Flags().BoolVarP(&recordEvent, "record-event", "", false, fmt.Sprintf("(optional) record a '%s' Event on each resource whose finalizers were removed, and on its namespace", terminate.EventReason))
+	cmd.PersistentFlags().IntVarP(&flags.loglevel, "loglevel", "v", 0, "log level for V logs (set to 1 or higher to display DEBUG messages)")
 
+	cmd.AddCommand(newExplainCommand(flags))
+	cmd.AddCommand(newRestoreCommand(flags))
+	cmd.AddCommand(newListCommand(flags))
+	cmd.AddCommand(newAuditCommand(flags))
 	return cmd
 }
 
-// getKubeconfigFile returns a file reader on (by order of match):
-// - the --kubeconfig CLI argument if it was provided
-// - the $KUBECONFIG file it the env var was set
-// - the <user_home_dir>/.kube/config file
-func getKubeconfigFile(kubeconfig string) (*os.File, error) {
-	var path string
-	if kubeconfig != "" {
-		path = kubeconfig
-	} else if kubeconfig = os.Getenv("KUBECONFIG"); kubeconfig != "" {
-		path = kubeconfig
-	} else {
-		path = filepath.Join(homeDir(), ".kube", "config")
+// parseResources returns the metadata of the resources to terminate, given the CLI args and selectors.
+// If the first arg does not contain a `/`, then it is assumed to be a kind, and all other args are the names
+// of the resources (of the same kind). Otherwise, all args are split in kind/name.
+// When a label or field selector is specified, a single arg with the kind of resources is expected.
+func parseResources(args []string, namespace, labelSelector, fieldSelector string) ([]terminate.ResourceMetadata, error) {
+	if labelSelector != "" || fieldSelector != "" {
+		if len(args) > 1 || strings.Contains(args[0], "/") {
+			return nil, fmt.Errorf("name cannot be provided when a selector is specified")
+		}
+		return []terminate.ResourceMetadata{
+			{
+				Kind:          args[0],
+				Namespace:     namespace,
+				LabelSelector: labelSelector,
+				FieldSelector: fieldSelector,
+			},
+		}, nil
+	}
+	resources := make([]terminate.ResourceMetadata, 0, len(args))
+	if !strings.Contains(args[0], "/") {
+		kind := args[0]
+		// all other args are the resource names (of the same kind)
+		for _, name := range args[1:] {
+			resources = append(resources, terminate.ResourceMetadata{
+				Kind:      kind,
+				Name:      name,
+				Namespace: namespace,
+			})
+		}
+		return resources, nil
+	}
+	for _, arg := range args {
+		kindname := strings.Split(arg, "/")
+		if len(kindname) != 2 {
+			return nil, fmt.Errorf("invalid resource name: %s", arg)
+		}
+		resources = append(resources, terminate.ResourceMetadata{
+			Kind:      kindname[0],
+			Name:      kindname[1],
+			Namespace: namespace,
+		})
+	}
+	return resources, nil
+}
+
+// getDryRunStrategy returns the dry-run strategy matching the given value of the `--dry-run` flag
+func getDryRunStrategy(dryRun string) (terminate.DryRunStrategy, error) {
+	switch s := terminate.DryRunStrategy(dryRun); s {
+	case terminate.DryRunNone, terminate.DryRunClient, terminate.DryRunServer:
+		return s, nil
+	default:
+		return "", fmt.Errorf(`invalid dry-run value (%v). Must be "none", "server", or "client"`, dryRun)
+	}
+}
+
+// getPropagationPolicy returns the deletion propagation policy matching the given value of the `--cascade` flag
+// (`true` and `false` are supported for compatibility with older versions of kubectl)
+func getPropagationPolicy(cascade string) (metav1.DeletionPropagation, error) {
+	switch cascade {
+	case "background", "true":
+		return metav1.DeletePropagationBackground, nil
+	case "foreground":
+		return metav1.DeletePropagationForeground, nil
+	case "orphan", "false":
+		return metav1.DeletePropagationOrphan, nil
+	default:
+		return "", fmt.Errorf(`invalid cascade value (%v). Must be "background", "foreground", or "orphan"`, cascade)
+	}
+}
+
+// getGracePeriod returns the grace period of the deletion, given the values of the `--grace-period` and `--force` flags
+// (same as with `kubectl delete`), or nil if the default grace period of the resources applies
+func getGracePeriod(gracePeriod int, force bool) *int64 {
+	if gracePeriod == 0 && !force {
+		// a zero grace period would force the deletion, so it is converted into the shortest graceful deletion instead
+		gracePeriod = 1
+	}
+	if force && gracePeriod < 0 {
+		gracePeriod = 0
+	}
+	if gracePeriod < 0 {
+		return nil
 	}
-	return os.Open(path)
+	g := int64(gracePeriod)
+	return &g
 }
 
-func homeDir() string {
-	if h := os.Getenv("HOME"); h != "" {
-		return h
+// getOutputFormat returns the output format matching the given value of the `--output` flag
+func getOutputFormat(output string) (terminate.OutputFormat, error) {
+	switch f := terminate.OutputFormat(output); f {
+	case terminate.OutputNone, terminate.OutputJSON, terminate.OutputYAML, terminate.OutputName, terminate.OutputWide:
+		return f, nil
+	default:
+		return "", fmt.Errorf(`invalid output format (%v). Must be "json", "yaml", "name", or "wide"`, output)
 	}
-	return os.Getenv("USERPROFILE") // windows
 }
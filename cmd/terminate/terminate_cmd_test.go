@@ -2,7 +2,13 @@ package terminate_test
 
 import (
 	"bytes"
+	"encoding/json"
+	"io/ioutil"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/xcoulon/kubectl-terminate/cmd/terminate"
@@ -11,6 +17,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
 func TestTerminateCmd(t *testing.T) {
@@ -28,6 +35,9 @@ func TestTerminateCmd(t *testing.T) {
 		}
 	}()
 	os.Unsetenv("KUBECONFIG")
+	// backups are saved in the user home by default
+	home, resetHome := setTempHome(t)
+	defer resetHome()
 
 	t.Run("ok", func(t *testing.T) {
 
@@ -45,6 +55,57 @@ func TestTerminateCmd(t *testing.T) {
 
 			})
 
+			t.Run("namespace with spec finalizers", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "namespace", "pasta")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "namespace \"pasta\" terminated (cleared spec.finalizers)\n", out)
+			})
+
+			t.Run("namespace with content", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "namespace", "pasta", "--content")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "pod \"penne\" terminated\n"+
+					"namespace \"pasta\": 1 remaining resource(s) terminated (pod: 1)\n"+
+					"namespace \"pasta\" terminated (cleared spec.finalizers)\n", out)
+			})
+
+			t.Run("namespace with cascade and without content", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				for _, cascade := range []string{"--cascade", "--cascade=background", "--cascade=foreground", "--cascade=orphan"} {
+					// when
+					out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "namespace", "pasta", cascade)
+					// then
+					require.NoError(t, err)
+					// the content of the namespace is not terminated
+					assert.Equal(t, "namespace \"pasta\" terminated (cleared spec.finalizers)\n", out)
+				}
+			})
+
+			t.Run("namespace with content and orphan cascade", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "namespace", "pasta", "--content", "--cascade=orphan")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "pod \"penne\" terminated\n"+
+					"namespace \"pasta\": 1 remaining resource(s) terminated (pod: 1)\n"+
+					"namespace \"pasta\" terminated (cleared spec.finalizers)\n", out)
+			})
+
 			t.Run("pod in dessert namespace", func(t *testing.T) {
 				// given
 				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
@@ -56,6 +117,410 @@ func TestTerminateCmd(t *testing.T) {
 			})
 		})
 
+		t.Run("resource not being deleted", func(t *testing.T) {
+
+			t.Run("skipped by default", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "deploy/latte")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "deploy \"latte\" skipped (not being deleted)\n", out)
+			})
+
+			t.Run("terminated with force-live", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--force-live", "deploy/latte")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "deploy \"latte\" terminated\n", out)
+			})
+		})
+
+		t.Run("selected finalizers", func(t *testing.T) {
+
+			t.Run("finalizer to remove", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--finalizer=cheese*", "--finalizer=*.example.com/*", "pod/cookie")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "pod \"cookie\": removed finalizers [cheesecake]\npod \"cookie\" terminated\n", out)
+			})
+
+			t.Run("finalizer to keep", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--keep-finalizer=cheesecake", "--dry-run=client", "pod/cookie")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "pod \"cookie\": would send DELETE /api/v1/namespaces/default/pods/cookie (propagationPolicy=Background)\npod \"cookie\" terminated (dry run)\n", out)
+			})
+		})
+
+		t.Run("dry run", func(t *testing.T) {
+
+			t.Run("client", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--dry-run=client", "pod/cookie", "namespace/pasta")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, `pod "cookie": would remove finalizers [cheesecake] from metadata.finalizers with PATCH /api/v1/namespaces/default/pods/cookie
+pod "cookie": would send DELETE /api/v1/namespaces/default/pods/cookie (propagationPolicy=Background)
+pod "cookie" terminated (dry run)
+namespace "pasta": would remove finalizers [kubernetes] from spec.finalizers with PUT /api/v1/namespaces/pasta/finalize
+namespace "pasta": would send DELETE /api/v1/namespaces/pasta (propagationPolicy=Background)
+namespace "pasta" terminated (dry run)
+`, out)
+			})
+
+			t.Run("server", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--dry-run=server", "pod/cookie")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "pod \"cookie\" terminated (server dry run)\n", out)
+			})
+		})
+
+		t.Run("with manifests", func(t *testing.T) {
+
+			t.Run("in file", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				manifest, err := ioutil.TempFile("", "manifest-*.yaml")
+				require.NoError(t, err)
+				defer os.Remove(manifest.Name())
+				_, err = manifest.WriteString("apiVersion: v1\nkind: Pod\nmetadata:\n  name: cookie\n---\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: latte\n")
+				require.NoError(t, err)
+				require.NoError(t, manifest.Close())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "-f", manifest.Name())
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "pod \"cookie\" terminated\n"+
+					"deployment \"latte\" skipped (not being deleted)\n", out)
+			})
+
+			t.Run("names in stdin", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				cmd := terminate.NewCommand()
+				cmd.SetIn(strings.NewReader("pod/cookie\ndeployment.apps/latte\n"))
+				// when
+				out, err := executeCommand(cmd, "--kubeconfig="+kubeconfig.Name(), "-f", "-")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "pod \"cookie\" terminated\n"+
+					"deployment.apps \"latte\" skipped (not being deleted)\n", out)
+			})
+		})
+
+		t.Run("with deletion options", func(t *testing.T) {
+
+			t.Run("cascade and grace period", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--dry-run=client", "--cascade=foreground", "--grace-period=10", "--keep-finalizer=cheesecake", "pod/cookie")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "pod \"cookie\": would send DELETE /api/v1/namespaces/default/pods/cookie (propagationPolicy=Foreground, gracePeriodSeconds=10)\n"+
+					"pod \"cookie\" terminated (dry run)\n", out)
+			})
+
+			t.Run("zero grace period without force", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--dry-run=client", "--cascade=false", "--grace-period=0", "--keep-finalizer=cheesecake", "pod/cookie")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "pod \"cookie\": would send DELETE /api/v1/namespaces/default/pods/cookie (propagationPolicy=Orphan, gracePeriodSeconds=1)\n"+
+					"pod \"cookie\" terminated (dry run)\n", out)
+			})
+
+			t.Run("force on pod on unreachable node", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--force", "--wait", "--timeout=5s", "pod/fudge")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "warning: Immediate deletion does not wait for confirmation that the running resource has been terminated. The resource may continue to run on the cluster indefinitely.\n"+
+					"pod \"fudge\" terminated\n", out)
+			})
+		})
+
+		t.Run("with selectors", func(t *testing.T) {
+
+			t.Run("pods with label selector", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "pod", "-l", "app=cookies")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "pod \"cookie\" terminated\npod \"cookie2\" terminated\n", out)
+			})
+
+			t.Run("pods with label selector above confirmation threshold", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "pod", "-l", "app=cookies", "--confirm-threshold=1", "--yes")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "pod \"cookie\" terminated\npod \"cookie2\" terminated\n", out)
+			})
+
+			t.Run("pods with field selector", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "pod", "--field-selector", "metadata.name=cookie")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "pod \"cookie\" terminated\n", out)
+			})
+
+			t.Run("no match", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "pod", "-l", "app=unknown")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "No resources found\n", out)
+			})
+		})
+
+		t.Run("with fully-qualified resource type", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "deployments.v1.apps/latte")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "deployments.v1.apps \"latte\" skipped (not being deleted)\n", out)
+		})
+
+		t.Run("in parallel", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--parallel=2", "pod", "cookie", "cookie2", "muffin")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "pod \"cookie\" terminated\npod \"cookie2\" terminated\npod \"muffin\" terminated\n", out)
+		})
+
+		t.Run("with output format", func(t *testing.T) {
+
+			t.Run("json", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				stdout, stderr, err := executeCommandWithStderr(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--no-backup", "-o", "json", "pod/cookie", "deploy/latte")
+				// then
+				require.NoError(t, err)
+				assert.JSONEq(t, `[
+					{"group":"","version":"v1","resource":"pods","namespace":"default","name":"cookie","finalizersRemoved":["cheesecake"],"deleteIssued":true,"state":"terminated","dryRun":"none"},
+					{"group":"apps","version":"v1","resource":"deployments","namespace":"default","name":"latte","finalizersRemoved":[],"deleteIssued":false,"state":"skipped","reason":"resource 'latte' is not being deleted","dryRun":"none"}
+				]`, stdout)
+				assert.Equal(t, "pod \"cookie\" terminated\ndeploy \"latte\" skipped (not being deleted)\n", stderr)
+			})
+
+			t.Run("json in dry-run", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				stdout, _, err := executeCommandWithStderr(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--no-backup", "--dry-run=client", "-o", "json", "pod/cookie")
+				// then
+				require.NoError(t, err)
+				assert.JSONEq(t, `[
+					{"group":"","version":"v1","resource":"pods","namespace":"default","name":"cookie","finalizersRemoved":["cheesecake"],"deleteIssued":false,"state":"terminated","dryRun":"client"}
+				]`, stdout)
+			})
+
+			t.Run("name", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				stdout, _, err := executeCommandWithStderr(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "-o", "name", "pod/cookie", "deploy/latte")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "pod/cookie\ndeployment.apps/latte\n", stdout)
+			})
+
+			t.Run("with failed resources", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				stdout, _, err := executeCommandWithStderr(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "-o", "wide", "--continue-on-error", "pod", "crumble", "cookie")
+				// then
+				require.Error(t, err)
+				assert.Equal(t, "NAMESPACE   NAME          RESOURCE   STATE        FINALIZERS REMOVED   DELETE ISSUED   ERROR\n"+
+					"default     pod/crumble   pods.v1.   failed       <none>               false           pods \"crumble\" is forbidden: User \"test\" cannot delete resource \"pods\" in the namespace \"default\"\n"+
+					"default     pod/cookie    pods.v1.   terminated   cheesecake           true            <none>\n", stdout)
+			})
+		})
+
+		t.Run("with backup", func(t *testing.T) {
+
+			t.Run("in default backup dir", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				stdout, _, err := executeCommandWithStderr(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "-o", "json", "pod/cookie")
+				// then
+				require.NoError(t, err)
+				records := []map[string]interface{}{}
+				require.NoError(t, json.Unmarshal([]byte(stdout), &records))
+				require.Len(t, records, 1)
+				assert.Regexp(t, "^"+regexp.QuoteMeta(filepath.Join(home, ".kube", "terminate", "backups"))+"/[0-9]{8}T[0-9]{6}Z/pods.v1/default/cookie.yaml$", records[0]["backup"])
+				assert.FileExists(t, records[0]["backup"].(string))
+			})
+
+			t.Run("in custom backup dir", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				dir, err := ioutil.TempDir("", "backups")
+				require.NoError(t, err)
+				defer os.RemoveAll(dir)
+				// when
+				_, err = executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--backup-dir="+dir, "pod/cookie")
+				// then
+				require.NoError(t, err)
+				backups, err := filepath.Glob(filepath.Join(dir, "*", "pods.v1", "default", "cookie.yaml"))
+				require.NoError(t, err)
+				assert.Len(t, backups, 1)
+			})
+
+			t.Run("disabled", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				dir, err := ioutil.TempDir("", "backups")
+				require.NoError(t, err)
+				defer os.RemoveAll(dir)
+				// when
+				_, err = executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--backup-dir="+dir, "--no-backup", "pod/cookie")
+				// then
+				require.NoError(t, err)
+				backups, err := ioutil.ReadDir(dir)
+				require.NoError(t, err)
+				assert.Empty(t, backups)
+			})
+		})
+
+		t.Run("with wait", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--wait", "--timeout=5s", "pod/donut")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "pod \"donut\" terminated\n", out)
+		})
+
+		t.Run("with wait and kept finalizer", func(t *testing.T) {
+			// given
+			server := test.NewServer(t)
+			defer server.Close()
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--wait", "--timeout=5s", "--keep-finalizer=example.com/keep", "pod/gelato")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "pod \"gelato\": removed finalizers [cheesecake]\npod \"gelato\" terminated\n", out)
+		})
+
+		t.Run("with wait and foreground cascade", func(t *testing.T) {
+			// given
+			server := test.NewServer(t)
+			defer server.Close()
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--wait", "--timeout=5s", "--cascade=foreground", "pod/gelato")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "pod \"gelato\" terminated\n", out)
+		})
+
+		t.Run("with unavailable API group", func(t *testing.T) {
+			// given
+			server := test.NewServer(t, "metrics.k8s.io")
+			defer server.Close()
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "ns", "pasta")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "WARNING: unable to retrieve the list of resources in 'metrics.k8s.io/v1beta1': the server is currently unable to handle the request\nns \"pasta\" terminated (cleared spec.finalizers)\n", out)
+		})
+
+		t.Run("with connection flags", func(t *testing.T) {
+
+			t.Run("context and server", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--context=unreachable", "--server="+server.URL, "pod", "cookie")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "pod \"cookie\" terminated\n", out)
+			})
+
+			t.Run("impersonation and timeout", func(t *testing.T) {
+				// given
+				_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+				defer os.Remove(kubeconfig.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--as=admin", "--as-group=system:masters", "--request-timeout=5s", "pod", "cookie")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "pod \"cookie\" terminated\n", out)
+			})
+		})
+
 		t.Run("with envvar kubeconfig", func(t *testing.T) {
 
 			t.Run("custom resource with splitted name", func(t *testing.T) {
@@ -76,21 +541,64 @@ func TestTerminateCmd(t *testing.T) {
 				require.NoError(t, err)
 			})
 
+			t.Run("multiple files merged", func(t *testing.T) {
+				// given
+				dev := test.NewContextKubeConfigFile(t, "dev", "http://127.0.0.1:1")
+				defer os.Remove(dev.Name())
+				prod := test.NewContextKubeConfigFile(t, "prod", server.URL)
+				defer os.Remove(prod.Name())
+				oldKubeConfig := os.Getenv("KUBECONFIG")
+				defer func() {
+					if oldKubeConfig != "" {
+						os.Setenv("KUBECONFIG", oldKubeConfig)
+					} else {
+						os.Unsetenv("KUBECONFIG")
+					}
+				}()
+				os.Setenv("KUBECONFIG", dev.Name()+string(filepath.ListSeparator)+prod.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "--context=prod", "-v=1", "pod", "cookie")
+				// then
+				require.NoError(t, err)
+				assert.Contains(t, out, "context 'dev' loaded from "+dev.Name()+"\n")
+				assert.Contains(t, out, "context 'prod' loaded from "+prod.Name()+"\n")
+				assert.Contains(t, out, "using server "+server.URL+"\n")
+				assert.Contains(t, out, "pod \"cookie\" terminated\n")
+			})
+
+			t.Run("current context of the first file", func(t *testing.T) {
+				// given
+				prod := test.NewContextKubeConfigFile(t, "prod", server.URL)
+				defer os.Remove(prod.Name())
+				dev := test.NewContextKubeConfigFile(t, "dev", "http://127.0.0.1:1")
+				defer os.Remove(dev.Name())
+				oldKubeConfig := os.Getenv("KUBECONFIG")
+				defer func() {
+					if oldKubeConfig != "" {
+						os.Setenv("KUBECONFIG", oldKubeConfig)
+					} else {
+						os.Unsetenv("KUBECONFIG")
+					}
+				}()
+				os.Setenv("KUBECONFIG", prod.Name()+string(filepath.ListSeparator)+"missing.yaml"+string(filepath.ListSeparator)+dev.Name())
+				// when
+				out, err := executeCommand(terminate.NewCommand(), "pod", "cookie")
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "pod \"cookie\" terminated\n", out)
+			})
 		})
 
 		t.Run("with userhome kubeconfig", func(t *testing.T) {
 
 			// given
-			homeDir, _ := test.NewKubeConfigFile(t, server.URL)
-			oldHome := os.Getenv("HOME")
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			// the path to the kubeconfig file in the user home is computed once for all
+			oldRecommendedHomeFile := clientcmd.RecommendedHomeFile
 			defer func() {
-				if oldHome != "" {
-					os.Setenv("HOME", oldHome)
-				} else {
-					os.Unsetenv("HOME")
-				}
+				clientcmd.RecommendedHomeFile = oldRecommendedHomeFile
 			}()
-			os.Setenv("HOME", homeDir)
+			clientcmd.RecommendedHomeFile = kubeconfig.Name()
 
 			t.Run("single resource", func(t *testing.T) {
 
@@ -129,27 +637,326 @@ func TestTerminateCmd(t *testing.T) {
 
 	})
 
+	t.Run("with protection", func(t *testing.T) {
+		// given
+		config := filepath.Join(home, ".config", "kubectl-terminate", "config.yaml")
+		err := os.MkdirAll(filepath.Dir(config), 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(config, []byte("protection:\n  contexts: [\"test-*\"]\n"), 0644)
+		require.NoError(t, err)
+		defer os.Remove(config)
+		_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+		defer os.Remove(kubeconfig.Name())
+
+		t.Run("protected context", func(t *testing.T) {
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "pod/cookie")
+			// then
+			require.Error(t, err)
+			assert.Equal(t, "refusing to terminate resources in protected context 'test-server' (use --override-protection to bypass the protection)", err.Error())
+			assert.Empty(t, out)
+		})
+
+		t.Run("unprotected context", func(t *testing.T) {
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--context=unreachable", "--server="+server.URL, "pod/cookie")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "pod \"cookie\" terminated\n", out)
+		})
+
+		t.Run("protected namespace from env var", func(t *testing.T) {
+			// given
+			os.Setenv("KUBECTL_TERMINATE_PROTECTION_CONTEXTS", "prod-*")
+			defer os.Unsetenv("KUBECTL_TERMINATE_PROTECTION_CONTEXTS")
+			os.Setenv("KUBECTL_TERMINATE_PROTECTION_NAMESPACES", "kube-system,default")
+			defer os.Unsetenv("KUBECTL_TERMINATE_PROTECTION_NAMESPACES")
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "pod/cookie")
+			// then
+			require.Error(t, err)
+			assert.Equal(t, `refusing to terminate pod "cookie" in protected namespace 'default' (use --override-protection to bypass the protection)`, err.Error())
+		})
+
+		t.Run("override protection", func(t *testing.T) {
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--override-protection", "pod/cookie")
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "pod \"cookie\" terminated\n", out)
+		})
+	})
+
+	t.Run("with events", func(t *testing.T) {
+		// given
+		_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+		defer os.Remove(kubeconfig.Name())
+		// when
+		out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--record-event", "pod/cookie")
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "pod \"cookie\" terminated\n", out) // no warning about the events
+	})
+
 	t.Run("failures", func(t *testing.T) {
 
 		t.Run("with invalid kubeconfig", func(t *testing.T) {
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "--kubeconfig=invalid", "pod", "cookie")
+			// then
+			require.Error(t, err)
+			assert.Equal(t, "error while loading kubeconfig: stat invalid: no such file or directory", err.Error())
+		})
+
+		t.Run("with unknown context", func(t *testing.T) {
 			// given
-			oldKubeConfig := os.Getenv("KUBECONFIG")
-			defer func() {
-				if oldKubeConfig != "" {
-					os.Setenv("KUBECONFIG", oldKubeConfig)
-				} else {
-					os.Unsetenv("KUBECONFIG")
-				}
-			}()
-			os.Setenv("KUBECONFIG", "invalid")
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--context=unknown", "pod", "cookie")
+			// then
+			require.Error(t, err)
+			assert.Equal(t, `error while loading kubeconfig: context "unknown" does not exist`, err.Error())
+		})
+
+		t.Run("with unreachable server", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--context=unreachable", "pod", "cookie")
+			// then
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "connection refused")
+		})
+
+		t.Run("with invalid dry-run value", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--dry-run=maybe", "pod/cookie")
+			// then
+			require.Error(t, err)
+			assert.Equal(t, `invalid dry-run value (maybe). Must be "none", "server", or "client"`, err.Error())
+		})
+
+		t.Run("with failed resources and continue-on-error", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--continue-on-error", "pod", "crumble", "unknown", "cookie")
+			// then
+			require.Error(t, err)
+			assert.Equal(t, `failed to terminate 1 resource(s): pod "crumble": pods "crumble" is forbidden: User "test" cannot delete resource "pods" in the namespace "default"`, err.Error())
+			assert.Equal(t, "pod \"crumble\" failed: pods \"crumble\" is forbidden: User \"test\" cannot delete resource \"pods\" in the namespace \"default\"\n"+
+				"pod \"unknown\" not found\n"+
+				"pod \"cookie\" terminated\n", out)
+		})
+
+		t.Run("with invalid parallel value", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--parallel=0", "pod/cookie")
+			// then
+			require.Error(t, err)
+			assert.Equal(t, "invalid parallel value (0). Must be greater than 0", err.Error())
+		})
+
+		t.Run("with re-blocked resource", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--wait", "--continue-on-error", "pod", "eclair", "donut")
+			// then
+			require.Error(t, err)
+			assert.Equal(t, `failed to terminate 1 resource(s): pod "eclair": resource 'eclair' was re-blocked by finalizer(s) [example.com/protection]`, err.Error())
+			assert.Equal(t, "pod \"eclair\" re-blocked by finalizer(s) [example.com/protection]\n"+
+				"pod \"donut\" terminated\n", out)
+		})
+
+		t.Run("with wait timeout", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--wait", "--timeout=100ms", "-n", "dessert", "pod/cookie2")
+			// then
+			require.Error(t, err)
+			assert.Equal(t, "timed out waiting for resource 'cookie2' to be removed", err.Error())
+		})
+
+		t.Run("with manifests and resources", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "-f", "-", "pod/cookie")
+			// then
+			require.EqualError(t, err, "resources and selectors cannot be provided when manifests are specified with -f")
+		})
+
+		t.Run("without resource", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name())
+			// then
+			require.EqualError(t, err, "you must specify the type and name of the resources to terminate, or manifests with -f")
+		})
+
+		t.Run("with ambiguous resource type", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
 			// when
-			_, err := executeCommand(terminate.NewCommand(), "pod", "cookie")
+			_, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "ck/cookie")
+			// then
+			require.EqualError(t, err, "ambiguous resource type: 'ck' matches cakes.v1.bakery.example.com, cupcakes.v1alpha1.patisserie.example.com (use one of these fully-qualified types instead)")
+		})
+
+		t.Run("with invalid cascade value", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--cascade=all", "pod/cookie")
+			// then
+			require.Error(t, err)
+			assert.Equal(t, `invalid cascade value (all). Must be "background", "foreground", or "orphan"`, err.Error())
+		})
+
+		t.Run("with invalid timeout value", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--wait", "--timeout=-1s", "pod/cookie")
+			// then
+			require.Error(t, err)
+			assert.Equal(t, "invalid timeout value (-1s). Must be greater than or equal to 0", err.Error())
+		})
+
+		t.Run("above confirmation threshold", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "pod", "-l", "app=cookies", "--confirm-threshold=1")
+			// then
+			require.Error(t, err)
+			assert.Equal(t, "2 resources selected for termination (more than 1): use --yes to confirm", err.Error())
+			assert.NotContains(t, out, "terminated")
+		})
+
+		t.Run("with namespace content above confirm-threshold", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			out, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "namespace", "pasta", "--content", "--confirm-threshold=1")
+			// then
+			require.Error(t, err)
+			// the content of the namespace is also counted
+			assert.Equal(t, "2 resources selected for termination (more than 1): use --yes to confirm", err.Error())
+			assert.NotContains(t, out, "terminated")
+		})
+
+		t.Run("with invalid confirm-threshold value", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "--confirm-threshold=-1", "pod/cookie")
+			// then
+			require.Error(t, err)
+			assert.Equal(t, "invalid confirm-threshold value (-1). Must be greater than or equal to 0", err.Error())
+		})
+
+		t.Run("with invalid output format", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "-o", "xml", "pod/cookie")
 			// then
 			require.Error(t, err)
-			assert.Equal(t, "error while locating KUBECONFIG: open invalid: no such file or directory", err.Error())
+			assert.Equal(t, `invalid output format (xml). Must be "json", "yaml", "name", or "wide"`, err.Error())
 		})
+
+		t.Run("with selector and name", func(t *testing.T) {
+			// given
+			_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+			defer os.Remove(kubeconfig.Name())
+			// when
+			_, err := executeCommand(terminate.NewCommand(), "--kubeconfig="+kubeconfig.Name(), "pod", "cookie", "-l", "app=cookies")
+			// then
+			require.Error(t, err)
+			assert.Equal(t, "name cannot be provided when a selector is specified", err.Error())
+		})
+	})
+
+}
+
+func TestInitAndExecute(t *testing.T) {
+
+	if args := os.Getenv("TEST_INIT_AND_EXECUTE_ARGS"); args != "" {
+		// running in the subprocess: execute the command and exit as the `kubectl-terminate` binary would do
+		os.Args = append([]string{"kubectl-terminate"}, strings.Split(args, " ")...)
+		terminate.InitAndExecute("test")
+		os.Exit(0)
+	}
+
+	// given
+	server := test.NewServer(t)
+	defer server.Close()
+	_, kubeconfig := test.NewKubeConfigFile(t, server.URL)
+	defer os.Remove(kubeconfig.Name())
+	home, err := ioutil.TempDir("", "home")
+	require.NoError(t, err)
+	defer os.RemoveAll(home)
+
+	t.Run("errors printed on stderr", func(t *testing.T) {
+		// given
+		cmd := exec.Command(os.Args[0], "-test.run=^TestInitAndExecute$")
+		cmd.Env = append(os.Environ(),
+			"HOME="+home,
+			"KUBECONFIG=",
+			"TEST_INIT_AND_EXECUTE_ARGS=--kubeconfig="+kubeconfig.Name()+" --no-backup -o json --continue-on-error pod crumble cookie")
+		stdout := new(bytes.Buffer)
+		stderr := new(bytes.Buffer)
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		// when
+		err := cmd.Run()
+		// then
+		require.IsType(t, &exec.ExitError{}, err)
+		assert.Equal(t, 2, err.(*exec.ExitError).ExitCode())
+		records := []map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &records), stdout.String())
+		require.Len(t, records, 2)
+		assert.Equal(t, "failed", records[0]["state"])
+		assert.Equal(t, "terminated", records[1]["state"])
+		assert.Contains(t, stderr.String(), "failed to terminate 1 resource(s)")
 	})
+}
 
+// setTempHome sets the `HOME` env var to a new temporary directory, so that the commands neither load the config
+// of the current user nor write in its audit log and backup dir. Returns the directory, and a func to restore the env var
+func setTempHome(t *testing.T) (string, func()) {
+	home, err := ioutil.TempDir("", "home")
+	require.NoError(t, err)
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", home)
+	return home, func() {
+		os.Setenv("HOME", oldHome)
+		os.RemoveAll(home)
+	}
 }
 
 // see https://github.com/spf13/cobra/blob/master/command_test.go#L16-L29
@@ -161,3 +968,14 @@ func executeCommand(cmd *cobra.Command, args ...string) (output string, err erro
 	_, err = cmd.ExecuteC()
 	return buf.String(), err
 }
+
+// executeCommandWithStderr executes the given command and returns its standard output and standard error separately
+func executeCommandWithStderr(cmd *cobra.Command, args ...string) (stdout, stderr string, err error) {
+	outBuf := new(bytes.Buffer)
+	errBuf := new(bytes.Buffer)
+	cmd.SetOut(outBuf)
+	cmd.SetErr(errBuf)
+	cmd.SetArgs(args)
+	_, err = cmd.ExecuteC()
+	return outBuf.String(), errBuf.String(), err
+}
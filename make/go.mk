@@ -24,9 +24,9 @@ build:
 	@echo "building with commit:$(BUILD_COMMIT) / tag:$(BUILD_TAG) / time:$(BUILD_TIME)"
 	@CGO_ENABLED=0 \
 		go build -ldflags \
-		"-X github.com/xcoulon/kubectl-terminate/BuildCommit=$(BUILD_COMMIT) \
-	    -X github.com/xcoulon/kubectl-terminate/main.BuildTag=$(BUILD_TAG) \
-	    -X github.com/xcoulon/kubectl-terminate/main.BuildTime=$(BUILD_TIME)" \
+		"-X main.BuildCommit=$(BUILD_COMMIT) \
+	    -X main.BuildTag=$(BUILD_TAG) \
+	    -X main.BuildTime=$(BUILD_TIME)" \
 		-o $(BINARY_PATH) \
 		cmd/main.go
 	@echo "$(BINARY_PATH) is ready to use"
@@ -38,7 +38,25 @@ func (l Logger) Info(msg string, args ...interface{}) {
 	fmt.Fprintln(l.out, fmt.Sprintf(msg, args...))
 }
 
+func (l Logger) Warn(msg string, args ...interface{}) {
+	c := color.New(color.FgHiYellow)
+	c.Fprintln(l.out, fmt.Sprintf("WARNING: "+msg, args...))
+}
+
 func (l Logger) Error(err error) {
 	c := color.New(color.FgHiRed)
 	c.Fprintln(l.out, fmt.Sprintf("%#v", err))
 }
+
+// WithOutput returns a copy of this logger which writes to the given output, with the same log level
+func (l Logger) WithOutput(out io.Writer) Logger {
+	return Logger{
+		out:      out,
+		loglevel: l.loglevel,
+	}
+}
+
+// Output returns the output of this logger
+func (l Logger) Output() io.Writer {
+	return l.out
+}
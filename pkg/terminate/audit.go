@@ -0,0 +1,217 @@
+package terminate
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DeleteAccepted the DELETE request was accepted
+	DeleteAccepted = "accepted"
+	// DeleteNotFound the resource was already gone when the DELETE request was sent
+	DeleteNotFound = "not-found"
+	// DeleteFailed the DELETE request failed
+	DeleteFailed = "failed"
+	// DeleteNotIssued no DELETE request was sent (eg: because the finalizers could not be removed)
+	DeleteNotIssued = "not-issued"
+)
+
+// AuditRecord the record of the modification of a resource
+type AuditRecord struct {
+	Timestamp             time.Time `json:"timestamp"`
+	User                  string    `json:"user"`
+	Context               string    `json:"context"`
+	Server                string    `json:"server"`
+	Group                 string    `json:"group"`
+	Version               string    `json:"version"`
+	Resource              string    `json:"resource"`
+	Namespace             string    `json:"namespace,omitempty"`
+	Name                  string    `json:"name"`
+	UID                   string    `json:"uid"`
+	ResourceVersionBefore string    `json:"resourceVersionBefore"`
+	ResourceVersionAfter  string    `json:"resourceVersionAfter"`
+	FinalizersRemoved     []string  `json:"finalizersRemoved"`
+	DeleteResult          string    `json:"deleteResult"`
+	Error                 string    `json:"error,omitempty"`
+	ToolVersion           string    `json:"toolVersion"`
+}
+
+// AuditLog the JSON-lines file in which a record is appended for each resource which is modified
+type AuditLog struct {
+	Path        string
+	User        string
+	Context     string
+	Server      string
+	ToolVersion string
+	lock        sync.Mutex
+}
+
+// newAuditRecord returns the record of the given resource before it is modified
+func newAuditRecord(apiresource metav1.APIResource, resource *unstructured.Unstructured) AuditRecord {
+	return AuditRecord{
+		Group:                 apiresource.Group,
+		Version:               apiresource.Version,
+		Resource:              apiresource.Name,
+		Namespace:             resource.GetNamespace(),
+		Name:                  resource.GetName(),
+		UID:                   string(resource.GetUID()),
+		ResourceVersionBefore: resource.GetResourceVersion(),
+		ResourceVersionAfter:  resource.GetResourceVersion(),
+		FinalizersRemoved:     []string{},
+		DeleteResult:          DeleteNotIssued,
+	}
+}
+
+// audit appends the given record in the audit log (if any), along with the outcome of the modification (ie, the given error),
+// and returns the latter, or the error which occurred while writing the record.
+// No record is appended with the server dry-run strategy, since the resources are not actually modified.
+func (o Options) audit(record AuditRecord, err error) error {
+	if o.AuditLog == nil || o.DryRun == DryRunServer {
+		return err
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if werr := o.AuditLog.append(record); werr != nil && err == nil {
+		return werr
+	}
+	return err
+}
+
+// append appends the given record in the audit log, along with the current time, user, context, server and tool version
+func (l *AuditLog) append(record AuditRecord) error {
+	record.Timestamp = time.Now().UTC()
+	record.User = l.User
+	record.Context = l.Context
+	record.Server = l.Server
+	record.ToolVersion = l.ToolVersion
+	if record.FinalizersRemoved == nil {
+		record.FinalizersRemoved = []string{}
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0700); err != nil {
+		return fmt.Errorf("unable to write audit log: %w", err)
+	}
+	f, err := os.OpenFile(l.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to write audit log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("unable to write audit log: %w", err)
+	}
+	return nil
+}
+
+// AuditFilter the criteria to select the records in the audit log (all records if empty)
+type AuditFilter struct {
+	// Since the minimum time of the records
+	Since     time.Time
+	Namespace string
+	Name      string
+	// Resource the resource type, optionally qualified with its group (eg: `pods` or `deployments.apps`)
+	Resource string
+}
+
+// matches returns 'true' if the given record matches all the criteria of this filter
+func (f AuditFilter) matches(r AuditRecord) bool {
+	if !f.Since.IsZero() && r.Timestamp.Before(f.Since) {
+		return false
+	}
+	if f.Namespace != "" && r.Namespace != f.Namespace {
+		return false
+	}
+	if f.Name != "" && r.Name != f.Name {
+		return false
+	}
+	if f.Resource != "" {
+		resource := strings.SplitN(f.Resource, ".", 2)
+		if resource[0] != r.Resource || (len(resource) == 2 && resource[1] != r.Group) {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadAuditLog returns the records in the audit log at the given path which match the given filter, in chronological order.
+// An empty list is returned if the audit log does not exist (yet)
+func ReadAuditLog(path string, filter AuditFilter) ([]AuditRecord, error) {
+	records := []AuditRecord{}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return records, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		r := AuditRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("invalid record at line %d in %s: %w", line, path, err)
+		}
+		if filter.matches(r) {
+			records = append(records, r)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// PrintAuditRecords prints the given audit records in the given format (in a table by default)
+func PrintAuditRecords(out io.Writer, format OutputFormat, records []AuditRecord) error {
+	switch format {
+	case OutputNone, OutputWide:
+		w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+		fmt.Fprintln(w, "TIMESTAMP\tUSER\tCONTEXT\tNAMESPACE\tNAME\tRESOURCE\tFINALIZERS REMOVED\tDELETE\tERROR") // nolint: errcheck
+		for _, r := range records {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", // nolint: errcheck
+				r.Timestamp.UTC().Format(time.RFC3339),
+				valueOrNone(r.User),
+				valueOrNone(r.Context),
+				valueOrNone(r.Namespace),
+				r.Name,
+				r.Resource+"."+r.Version+"."+r.Group,
+				valueOrNone(strings.Join(r.FinalizersRemoved, ",")),
+				r.DeleteResult,
+				valueOrNone(r.Error))
+		}
+		return w.Flush()
+	case OutputJSON:
+		data, err := json.MarshalIndent(records, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	case OutputYAML:
+		data, err := yaml.Marshal(records)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(data)
+		return err
+	default:
+		return fmt.Errorf("unsupported output format: '%s'", format)
+	}
+}
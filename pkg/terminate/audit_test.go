@@ -0,0 +1,209 @@
+package terminate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestAuditLog(t *testing.T) {
+
+	// given
+	log := logger.NewLogger(os.Stdout, 0)
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	newAuditLog := func(name string) *AuditLog {
+		return &AuditLog{
+			Path:        filepath.Join(dir, name, "audit.log"),
+			User:        "jdoe",
+			Context:     "test-server",
+			Server:      "https://127.0.0.1:6443",
+			ToolVersion: "v1.0.0",
+		}
+	}
+
+	t.Run("terminated resources", func(t *testing.T) {
+		// given
+		kubeconfig, server := setup(t)
+		defer server.Close()
+		auditLog := newAuditLog("terminated")
+		metadata := []ResourceMetadata{
+			{
+				Kind: "pod",
+				Name: "cookie",
+			},
+			{
+				Kind: "deploy",
+				Name: "latte", // not being deleted, so not modified
+			},
+		}
+		// when
+		_, err := Terminate(metadata, kubeconfig, Options{AuditLog: auditLog}, log)
+		// then
+		require.NoError(t, err)
+		records, err := ReadAuditLog(auditLog.Path, AuditFilter{})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		r := records[0]
+		assert.WithinDuration(t, time.Now(), r.Timestamp, time.Minute)
+		assert.Equal(t, "jdoe", r.User)
+		assert.Equal(t, "test-server", r.Context)
+		assert.Equal(t, "https://127.0.0.1:6443", r.Server)
+		assert.Equal(t, "v1.0.0", r.ToolVersion)
+		assert.Equal(t, "pods", r.Resource)
+		assert.Equal(t, "v1", r.Version)
+		assert.Equal(t, "default", r.Namespace)
+		assert.Equal(t, "cookie", r.Name)
+		assert.Equal(t, "1", r.ResourceVersionBefore)
+		assert.Equal(t, []string{"cheesecake"}, r.FinalizersRemoved)
+		assert.Equal(t, DeleteAccepted, r.DeleteResult)
+		assert.Empty(t, r.Error)
+	})
+
+	t.Run("finalized namespace", func(t *testing.T) {
+		// given
+		kubeconfig, server := setup(t)
+		defer server.Close()
+		auditLog := newAuditLog("namespace")
+		metadata := []ResourceMetadata{
+			{
+				Kind: "namespace",
+				Name: "pasta",
+			},
+		}
+		// when
+		_, err := Terminate(metadata, kubeconfig, Options{AuditLog: auditLog}, log)
+		// then
+		require.NoError(t, err)
+		records, err := ReadAuditLog(auditLog.Path, AuditFilter{})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, "namespaces", records[0].Resource)
+		assert.Equal(t, []string{"kubernetes"}, records[0].FinalizersRemoved)
+		// the namespace was only modified through the 'finalize' subresource
+		assert.Equal(t, "1", records[0].ResourceVersionBefore)
+		assert.Equal(t, "2", records[0].ResourceVersionAfter)
+	})
+
+	t.Run("failed deletion", func(t *testing.T) {
+		// given
+		kubeconfig, server := setup(t)
+		defer server.Close()
+		auditLog := newAuditLog("failed")
+		metadata := []ResourceMetadata{
+			{
+				Kind: "pod",
+				Name: "crumble",
+			},
+		}
+		// when
+		_, err := Terminate(metadata, kubeconfig, Options{AuditLog: auditLog}, log)
+		// then
+		require.Error(t, err)
+		assert.True(t, errors.IsForbidden(err))
+		records, err := ReadAuditLog(auditLog.Path, AuditFilter{})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, "crumble", records[0].Name)
+		assert.Equal(t, []string{"cheesecake"}, records[0].FinalizersRemoved)
+		assert.Equal(t, DeleteFailed, records[0].DeleteResult)
+		assert.Contains(t, records[0].Error, "forbidden")
+	})
+
+	t.Run("no record in dry-run", func(t *testing.T) {
+		// given
+		kubeconfig, server := setup(t)
+		defer server.Close()
+		metadata := []ResourceMetadata{
+			{
+				Kind: "pod",
+				Name: "cookie",
+			},
+		}
+		for _, dryRun := range []DryRunStrategy{DryRunClient, DryRunServer} {
+			auditLog := newAuditLog("dry-run-" + string(dryRun))
+			// when
+			_, err := Terminate(metadata, kubeconfig, Options{AuditLog: auditLog, DryRun: dryRun}, log)
+			// then
+			require.NoError(t, err)
+			_, err = os.Stat(auditLog.Path)
+			assert.True(t, os.IsNotExist(err))
+		}
+	})
+}
+
+func TestReadAuditLog(t *testing.T) {
+
+	// given
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+	err = ioutil.WriteFile(path, []byte(`{"timestamp":"2020-03-01T12:00:00Z","group":"","version":"v1","resource":"pods","namespace":"default","name":"cookie"}
+{"timestamp":"2020-03-02T12:00:00Z","group":"apps","version":"v1","resource":"deployments","namespace":"default","name":"latte"}
+
+{"timestamp":"2020-03-03T12:00:00Z","group":"","version":"v1","resource":"namespaces","name":"pasta"}
+`), 0600)
+	require.NoError(t, err)
+	names := func(records []AuditRecord) []string {
+		result := []string{}
+		for _, r := range records {
+			result = append(result, r.Name)
+		}
+		return result
+	}
+
+	t.Run("all records", func(t *testing.T) {
+		// when
+		records, err := ReadAuditLog(path, AuditFilter{})
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cookie", "latte", "pasta"}, names(records))
+	})
+
+	t.Run("filtered records", func(t *testing.T) {
+		// when
+		since, err := ReadAuditLog(path, AuditFilter{Since: time.Date(2020, time.March, 2, 0, 0, 0, 0, time.UTC)})
+		require.NoError(t, err)
+		namespace, err := ReadAuditLog(path, AuditFilter{Namespace: "default"})
+		require.NoError(t, err)
+		resource, err := ReadAuditLog(path, AuditFilter{Resource: "deployments.apps"})
+		require.NoError(t, err)
+		name, err := ReadAuditLog(path, AuditFilter{Resource: "pods", Name: "latte"})
+		require.NoError(t, err)
+		// then
+		assert.Equal(t, []string{"latte", "pasta"}, names(since))
+		assert.Equal(t, []string{"cookie", "latte"}, names(namespace))
+		assert.Equal(t, []string{"latte"}, names(resource))
+		assert.Empty(t, names(name))
+	})
+
+	t.Run("missing audit log", func(t *testing.T) {
+		// when
+		records, err := ReadAuditLog(filepath.Join(dir, "unknown.log"), AuditFilter{})
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, records)
+	})
+
+	t.Run("invalid record", func(t *testing.T) {
+		// given
+		invalid := filepath.Join(dir, "invalid.log")
+		err := ioutil.WriteFile(invalid, []byte("{\"name\":\"cookie\"}\nnot json\n"), 0600)
+		require.NoError(t, err)
+		// when
+		_, err = ReadAuditLog(invalid, AuditFilter{})
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid record at line 2 in "+invalid)
+	})
+}
@@ -0,0 +1,42 @@
+package terminate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// backupDirName returns the name of the backup directory for the given API resource type,
+// eg: `pods.v1` or `deployments.v1.apps`
+func backupDirName(apiresource metav1.APIResource) string {
+	if apiresource.Group == "" {
+		return apiresource.Name + "." + apiresource.Version
+	}
+	return apiresource.Name + "." + apiresource.Version + "." + apiresource.Group
+}
+
+// backupPath returns the path to the backup file of the given resource in the given directory,
+// i.e., `<dir>/<resource>.<version>[.<group>]/<namespace>/<name>.yaml` (without the namespace for cluster-scoped resources)
+func backupPath(dir string, apiresource metav1.APIResource, resource *unstructured.Unstructured) string {
+	if resource.GetNamespace() == "" {
+		return filepath.Join(dir, backupDirName(apiresource), resource.GetName()+".yaml")
+	}
+	return filepath.Join(dir, backupDirName(apiresource), resource.GetNamespace(), resource.GetName()+".yaml")
+}
+
+// backupResource writes the full given resource in a YAML file in the given directory, and returns the path to this file
+func backupResource(dir string, apiresource metav1.APIResource, resource *unstructured.Unstructured) (string, error) {
+	path := backupPath(dir, apiresource, resource)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	data, err := yaml.Marshal(resource.Object)
+	if err != nil {
+		return "", err
+	}
+	return path, ioutil.WriteFile(path, data, 0600)
+}
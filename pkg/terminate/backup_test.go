@@ -0,0 +1,73 @@
+package terminate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func TestBackupPath(t *testing.T) {
+
+	t.Run("core namespaced resource", func(t *testing.T) {
+		// given
+		r := &unstructured.Unstructured{}
+		r.SetNamespace("default")
+		r.SetName("cookie")
+		// when
+		p := backupPath("/backups", metav1.APIResource{Version: "v1", Name: "pods"}, r)
+		// then
+		assert.Equal(t, filepath.Join("/backups", "pods.v1", "default", "cookie.yaml"), p)
+	})
+
+	t.Run("namespaced resource in group", func(t *testing.T) {
+		// given
+		r := &unstructured.Unstructured{}
+		r.SetNamespace("default")
+		r.SetName("latte")
+		// when
+		p := backupPath("/backups", metav1.APIResource{Group: "apps", Version: "v1", Name: "deployments"}, r)
+		// then
+		assert.Equal(t, filepath.Join("/backups", "deployments.v1.apps", "default", "latte.yaml"), p)
+	})
+
+	t.Run("cluster-scoped resource", func(t *testing.T) {
+		// given
+		r := &unstructured.Unstructured{}
+		r.SetName("pasta")
+		// when
+		p := backupPath("/backups", metav1.APIResource{Version: "v1", Name: "namespaces"}, r)
+		// then
+		assert.Equal(t, filepath.Join("/backups", "namespaces.v1", "pasta.yaml"), p)
+	})
+}
+
+func TestBackupResource(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "backups")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	r := &unstructured.Unstructured{}
+	r.SetAPIVersion("v1")
+	r.SetKind("Pod")
+	r.SetNamespace("default")
+	r.SetName("cookie")
+	r.SetFinalizers([]string{"cheesecake"})
+	// when
+	p, err := backupResource(dir, metav1.APIResource{Version: "v1", Name: "pods"}, r)
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "pods.v1", "default", "cookie.yaml"), p)
+	data, err := ioutil.ReadFile(p)
+	require.NoError(t, err)
+	actual := &unstructured.Unstructured{}
+	err = yaml.Unmarshal(data, &actual.Object)
+	require.NoError(t, err)
+	assert.Equal(t, r, actual)
+}
@@ -0,0 +1,105 @@
+package terminate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// loadNamespaceContent returns the tasks to terminate the resources which are being deleted and which still hold finalizers
+// in the given namespace, among all the namespaced resource types which can be listed.
+// With `opts.ContinueOnError`, the resources which could not be loaded are returned as tasks with an error.
+func loadNamespaceContent(kubeconfig clientcmd.ClientConfig, discoveryClient discovery.DiscoveryInterface, namespace string, opts Options, log logger.Logger) ([]task, error) {
+	log.Debug("looking up the remaining resources in namespace '%s'", namespace)
+	apiresources, err := listableResources(discoveryClient, true, log)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := newDynamicClient(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	tasks := []task{}
+	for _, r := range findFinalizedResources(dynamicClient, apiresources, namespace, log) {
+		if r.DeletionTimestamp == nil {
+			continue // not being deleted, so its finalizers are not blocking the namespace deletion
+		}
+		cl := dynamicClient.Resource(schema.GroupVersionResource{
+			Group:    r.APIResource.Group,
+			Version:  r.APIResource.Version,
+			Resource: r.APIResource.Name,
+		}).Namespace(namespace)
+		t := task{
+			cl:          cl,
+			apiresource: r.APIResource,
+			kind:        strings.ToLower(r.APIResource.Kind),
+			namespace:   namespace,
+			name:        r.Name,
+		}
+		t.resource, err = cl.Get(r.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue // already gone
+		} else if err != nil && !opts.ContinueOnError {
+			return nil, err
+		}
+		t.err = err
+		tasks = append(tasks, t)
+	}
+	return tasks, nil
+}
+
+// terminateNamespaceContent terminates the resources of the given tasks, which were loaded from the given namespace
+func terminateNamespaceContent(namespace string, content []task, opts Options, log logger.Logger) ([]Result, error) {
+	results := []Result{}
+	for _, t := range content {
+		if t.declined {
+			results = append(results, declinedResult(t, log))
+			continue
+		}
+		result, err := Result{APIResource: t.apiresource, Kind: t.kind, Namespace: namespace, Name: t.name}, t.err
+		if err == nil {
+			if result, err = terminateResource(t.cl, t.apiresource, t.kind, t.resource, opts, log); err == nil {
+				results = append(results, result)
+				continue
+			}
+		}
+		results = append(results, failedResult(result, err, log))
+		if !opts.ContinueOnError {
+			return results, err
+		}
+	}
+	log.Info("namespace \"%s\": %s", namespace, summary(results))
+	return results, nil
+}
+
+// summary returns the number of terminated resources, per type (in order of appearance)
+func summary(results []Result) string {
+	kinds := []string{}
+	counts := map[string]int{}
+	total := 0
+	for _, r := range results {
+		if r.Status != StatusTerminated {
+			continue
+		}
+		if _, exists := counts[r.Kind]; !exists {
+			kinds = append(kinds, r.Kind)
+		}
+		counts[r.Kind]++
+		total++
+	}
+	if total == 0 {
+		return "no remaining resource to terminate"
+	}
+	details := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		details = append(details, fmt.Sprintf("%s: %d", k, counts[k]))
+	}
+	return fmt.Sprintf("%d remaining resource(s) terminated (%s)", total, strings.Join(details, ", "))
+}
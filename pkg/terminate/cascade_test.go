@@ -0,0 +1,31 @@
+package terminate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummary(t *testing.T) {
+
+	t.Run("terminated resources", func(t *testing.T) {
+		// given
+		results := []Result{
+			{Kind: "pod", Name: "penne", Status: StatusTerminated},
+			{Kind: "customtype", Name: "farfalle", Status: StatusTerminated},
+			{Kind: "pod", Name: "fusilli", Status: StatusTerminated},
+			{Kind: "pod", Name: "orzo", Status: StatusSkipped},
+		}
+		// when
+		s := summary(results)
+		// then
+		assert.Equal(t, "3 remaining resource(s) terminated (pod: 2, customtype: 1)", s)
+	})
+
+	t.Run("no terminated resource", func(t *testing.T) {
+		// when
+		s := summary([]Result{})
+		// then
+		assert.Equal(t, "no remaining resource to terminate", s)
+	})
+}
@@ -0,0 +1,67 @@
+package terminate
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Review a resource which is submitted for confirmation before it is terminated
+type Review struct {
+	Kind              string
+	Namespace         string
+	Name              string
+	DeletionTimestamp *metav1.Time
+	// Finalizers the finalizers which would be removed (including the ones in the spec of a namespace)
+	Finalizers []string
+}
+
+// ConfirmFunc returns whether each of the given resources should be terminated, in the same order
+type ConfirmFunc func(reviews []Review) ([]bool, error)
+
+// confirmTasks submits the resources of the given tasks for confirmation, and marks the tasks which were not confirmed.
+// The content of a namespace (see `Options.Content`) is submitted before the namespace itself (ie, in the order of termination).
+// Resources which could not be loaded or which would be skipped anyway (ie, which are not being deleted) are not submitted.
+func confirmTasks(tasks []task, opts Options) error {
+	reviews, submitted := reviewTasks(tasks, newFinalizerFilter(opts), opts)
+	if len(reviews) == 0 {
+		return nil
+	}
+	confirmed, err := opts.Confirm(reviews)
+	if err != nil {
+		return err
+	}
+	for i, t := range submitted {
+		t.declined = i >= len(confirmed) || !confirmed[i]
+	}
+	return nil
+}
+
+// reviewTasks returns the reviews of the resources of the given tasks (and of their content), along with the matching tasks
+func reviewTasks(tasks []task, filter finalizerFilter, opts Options) ([]Review, []*task) {
+	reviews := []Review{}
+	submitted := []*task{}
+	for i := range tasks {
+		t := &tasks[i]
+		r, s := reviewTasks(t.content, filter, opts)
+		reviews = append(reviews, r...)
+		submitted = append(submitted, s...)
+		if t.err != nil || (checkDeletionTimestamp(t.resource) != nil && !opts.ForceLive) {
+			continue
+		}
+		finalizers, _ := filter.split(t.resource.GetFinalizers())
+		if isNamespace(t.apiresource) {
+			specFinalizers, _, _ := unstructured.NestedStringSlice(t.resource.Object, "spec", "finalizers")
+			removed, _ := filter.split(specFinalizers)
+			finalizers = append(finalizers, removed...)
+		}
+		reviews = append(reviews, Review{
+			Kind:              t.kind,
+			Namespace:         t.namespace,
+			Name:              t.name,
+			DeletionTimestamp: t.resource.GetDeletionTimestamp(),
+			Finalizers:        finalizers,
+		})
+		submitted = append(submitted, t)
+	}
+	return reviews, submitted
+}
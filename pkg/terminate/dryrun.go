@@ -0,0 +1,130 @@
+package terminate
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DryRunStrategy the dry-run strategy when terminating resources
+type DryRunStrategy string
+
+const (
+	// DryRunNone the resources are actually modified and deleted
+	DryRunNone DryRunStrategy = "none"
+	// DryRunClient the changes are only printed, no write request is sent to the server
+	DryRunClient DryRunStrategy = "client"
+	// DryRunServer the write requests are sent to the server, which does not persist the changes
+	DryRunServer DryRunStrategy = "server"
+)
+
+// suffix returns the suffix to append to the messages of the given dry-run strategy
+func (s DryRunStrategy) suffix() string {
+	switch s {
+	case DryRunClient:
+		return " (dry run)"
+	case DryRunServer:
+		return " (server dry run)"
+	default:
+		return ""
+	}
+}
+
+func patchOptions(opts Options) metav1.PatchOptions {
+	if opts.DryRun == DryRunServer {
+		return metav1.PatchOptions{
+			DryRun: []string{metav1.DryRunAll},
+		}
+	}
+	return metav1.PatchOptions{}
+}
+
+func updateOptions(opts Options) metav1.UpdateOptions {
+	if opts.DryRun == DryRunServer {
+		return metav1.UpdateOptions{
+			DryRun: []string{metav1.DryRunAll},
+		}
+	}
+	return metav1.UpdateOptions{}
+}
+
+func deleteOptions(opts Options) *metav1.DeleteOptions {
+	o := &metav1.DeleteOptions{
+		GracePeriodSeconds: opts.GracePeriodSeconds,
+	}
+	if opts.PropagationPolicy != "" {
+		policy := opts.PropagationPolicy
+		o.PropagationPolicy = &policy
+	}
+	if opts.DryRun == DryRunServer {
+		o.DryRun = []string{metav1.DryRunAll}
+	}
+	return o
+}
+
+// deleteDetails returns the non-default options of the DELETE request, eg: ` (propagationPolicy=Foreground, gracePeriodSeconds=0)`
+func deleteDetails(opts Options) string {
+	details := []string{}
+	if opts.PropagationPolicy != "" {
+		details = append(details, fmt.Sprintf("propagationPolicy=%s", opts.PropagationPolicy))
+	}
+	if opts.GracePeriodSeconds != nil {
+		details = append(details, fmt.Sprintf("gracePeriodSeconds=%d", *opts.GracePeriodSeconds))
+	}
+	if len(details) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(details, ", ") + ")"
+}
+
+// resourcePath returns the path of the given resource on the API server,
+// eg: `/api/v1/namespaces/default/pods/cookie` or `/apis/apps/v1/namespaces/default/deployments/latte`
+func resourcePath(apiresource metav1.APIResource, namespace, name string) string {
+	p := path.Join("/apis", apiresource.Group, apiresource.Version)
+	if apiresource.Group == "" {
+		p = path.Join("/api", apiresource.Version)
+	}
+	if apiresource.Namespaced {
+		p = path.Join(p, "namespaces", namespace)
+	}
+	return path.Join(p, apiresource.Name, name)
+}
+
+// dryRunResource prints the finalizers that would be removed on the given resource,
+// and the requests that would be sent to the server, without sending any of them.
+func dryRunResource(apiresource metav1.APIResource, kind string, resource *unstructured.Unstructured, filter finalizerFilter, opts Options, result Result, log logger.Logger) (Result, error) {
+	name := resource.GetName()
+	p := resourcePath(apiresource, resource.GetNamespace(), name)
+	r := resource.DeepCopy() // do not modify the given resource
+	cleared := []string{}
+	removed, err := removeFinalizers(r, filter)
+	if err != nil {
+		return result, err
+	}
+	if len(removed) > 0 {
+		log.Info("%s \"%s\": would remove finalizers %v from metadata.finalizers with PATCH %s", kind, name, removed, p)
+		cleared = append(cleared, "metadata.finalizers")
+	}
+	if isNamespace(apiresource) {
+		specRemoved, err := removeSpecFinalizers(r, filter)
+		if err != nil {
+			return result, err
+		}
+		if len(specRemoved) > 0 {
+			log.Info("%s \"%s\": would remove finalizers %v from spec.finalizers with PUT %s/finalize", kind, name, specRemoved, p)
+			cleared = append(cleared, "spec.finalizers")
+			removed = append(removed, specRemoved...)
+		}
+	}
+	log.Info("%s \"%s\": would send DELETE %s%s", kind, name, p, deleteDetails(opts))
+	result.Status = StatusTerminated
+	result.Cleared = cleared
+	result.Finalizers = removed
+	log.Info("%s \"%s\" terminated%s", kind, name, DryRunClient.suffix())
+	return result, nil
+}
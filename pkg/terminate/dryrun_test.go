@@ -0,0 +1,80 @@
+package terminate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResourcePath(t *testing.T) {
+
+	t.Run("core namespaced resource", func(t *testing.T) {
+		// when
+		p := resourcePath(metav1.APIResource{
+			Version:    "v1",
+			Name:       "pods",
+			Namespaced: true,
+		}, "default", "cookie")
+		// then
+		assert.Equal(t, "/api/v1/namespaces/default/pods/cookie", p)
+	})
+
+	t.Run("core cluster-scoped resource", func(t *testing.T) {
+		// when
+		p := resourcePath(metav1.APIResource{
+			Version: "v1",
+			Name:    "namespaces",
+		}, "", "pasta")
+		// then
+		assert.Equal(t, "/api/v1/namespaces/pasta", p)
+	})
+
+	t.Run("custom namespaced resource", func(t *testing.T) {
+		// when
+		p := resourcePath(metav1.APIResource{
+			Group:      "customdomain",
+			Version:    "v1beta1",
+			Name:       "customtypes",
+			Namespaced: true,
+		}, "dessert", "cookie")
+		// then
+		assert.Equal(t, "/apis/customdomain/v1beta1/namespaces/dessert/customtypes/cookie", p)
+	})
+}
+
+func TestDeleteOptions(t *testing.T) {
+
+	t.Run("default", func(t *testing.T) {
+		// when
+		o := deleteOptions(Options{})
+		// then
+		assert.Equal(t, &metav1.DeleteOptions{}, o)
+		assert.Empty(t, deleteDetails(Options{}))
+	})
+
+	t.Run("server dry run", func(t *testing.T) {
+		// when
+		o := deleteOptions(Options{DryRun: DryRunServer})
+		// then
+		assert.Equal(t, []string{metav1.DryRunAll}, o.DryRun)
+	})
+
+	t.Run("propagation policy and grace period", func(t *testing.T) {
+		// given
+		gracePeriod := int64(0)
+		opts := Options{
+			PropagationPolicy:  metav1.DeletePropagationOrphan,
+			GracePeriodSeconds: &gracePeriod,
+		}
+		// when
+		o := deleteOptions(opts)
+		// then
+		require.NotNil(t, o.PropagationPolicy)
+		assert.Equal(t, metav1.DeletePropagationOrphan, *o.PropagationPolicy)
+		require.NotNil(t, o.GracePeriodSeconds)
+		assert.Equal(t, int64(0), *o.GracePeriodSeconds)
+		assert.Equal(t, " (propagationPolicy=Orphan, gracePeriodSeconds=0)", deleteDetails(opts))
+	})
+}
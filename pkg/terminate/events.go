@@ -0,0 +1,151 @@
+package terminate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// EventReason the reason of the Events recorded on the terminated resources
+	EventReason = "FinalizersForcefullyRemoved"
+	// EventComponent the component which reports the Events
+	EventComponent = "kubectl-terminate"
+)
+
+var eventsResource = schema.GroupVersionResource{Version: "v1", Resource: "events"}
+
+var namespacesResource = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
+// EventRecorder records a `Warning` Event on each terminated resource and on its namespace,
+// so that the intervention shows up in `kubectl get events` and in the event exporters
+type EventRecorder struct {
+	// User the user who terminates the resources
+	User string
+	// KubernetesUser the Kubernetes user as whom the requests were sent (eg: the `--as` user, or the user of the kubeconfig context)
+	KubernetesUser string
+	client         dynamic.Interface
+}
+
+// NewEventRecorder returns a new EventRecorder for the cluster of the given kubeconfig
+func NewEventRecorder(kubeconfig clientcmd.ClientConfig, user, kubernetesUser string) (*EventRecorder, error) {
+	cl, err := newDynamicClient(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	return &EventRecorder{
+		User:           user,
+		KubernetesUser: kubernetesUser,
+		client:         cl,
+	}, nil
+}
+
+// record records an Event on the given resource whose finalizers were removed, and another one on its namespace
+// (unless the resource is cluster-scoped or is the namespace itself).
+// If the namespace is terminating, no Event can be created in it, so only the Event on the namespace is recorded, in the 'default' namespace.
+// Events are recorded on a best-effort basis: a failure is only logged as a warning, since the resource was already terminated.
+func (r *EventRecorder) record(kind string, resource *unstructured.Unstructured, removed []string, log logger.Logger) {
+	name := resource.GetName()
+	now := metav1.Now()
+	namespace := resource.GetNamespace()
+	involved := corev1.ObjectReference{
+		APIVersion:      resource.GetAPIVersion(),
+		Kind:            resource.GetKind(),
+		Namespace:       namespace,
+		Name:            name,
+		UID:             resource.GetUID(),
+		ResourceVersion: resource.GetResourceVersion(),
+	}
+	message := fmt.Sprintf("Finalizers %v forcefully removed by %s with %s", removed, r.author(), EventComponent)
+	if namespace == "" {
+		// same as the event recorder of client-go, events on cluster-scoped resources are recorded in the 'default' namespace
+		if err := r.create(newEvent(metav1.NamespaceDefault, involved, message, now)); err != nil {
+			log.Warn("unable to record event on %s \"%s\": %v", kind, name, err)
+		}
+		return
+	}
+	terminating := r.terminating(namespace, log)
+	if !terminating {
+		if err := r.create(newEvent(namespace, involved, message, now)); err != nil {
+			log.Warn("unable to record event on %s \"%s\": %v", kind, name, err)
+		}
+	}
+	eventNamespace := namespace
+	involved = corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Namespace",
+		Namespace:  namespace, // the API server requires the namespace of the event and of its object to match
+		Name:       namespace,
+	}
+	if terminating {
+		// same as for the cluster-scoped resources, since the namespace does not accept new content anymore
+		eventNamespace = metav1.NamespaceDefault
+		involved.Namespace = ""
+	}
+	message = fmt.Sprintf("Finalizers %v forcefully removed from %s \"%s\" by %s with %s", removed, kind, name, r.author(), EventComponent)
+	if err := r.create(newEvent(eventNamespace, involved, message, now)); err != nil {
+		log.Warn("unable to record event on namespace \"%s\": %v", namespace, err)
+	}
+}
+
+// author returns the user who terminates the resources, along with the Kubernetes user (if known)
+func (r *EventRecorder) author() string {
+	if r.KubernetesUser == "" {
+		return r.User
+	}
+	return fmt.Sprintf("%s as %s", r.User, r.KubernetesUser)
+}
+
+// terminating returns 'true' if the given namespace is being deleted
+func (r *EventRecorder) terminating(namespace string, log logger.Logger) bool {
+	ns, err := r.client.Resource(namespacesResource).Get(namespace, metav1.GetOptions{})
+	if err != nil {
+		log.Debug("unable to check if namespace '%s' is terminating: %v", namespace, err)
+		return false
+	}
+	return ns.GetDeletionTimestamp() != nil
+}
+
+// newEvent returns a new Event in the given namespace, about the given object
+func newEvent(namespace string, involved corev1.ObjectReference, message string, now metav1.Time) *corev1.Event {
+	return &corev1.Event{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Event",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			// same naming as the events recorded by the controllers, along with the kind of the object, so that the names
+			// of the events on a resource and on its namespace differ even if they have the same name
+			Name:      fmt.Sprintf("%v.%s.%x", involved.Name, strings.ToLower(involved.Kind), now.UnixNano()),
+			Namespace: namespace,
+		},
+		InvolvedObject: involved,
+		Reason:         EventReason,
+		Message:        message,
+		Source: corev1.EventSource{
+			Component: EventComponent,
+		},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+		Type:           corev1.EventTypeWarning,
+	}
+}
+
+func (r *EventRecorder) create(event *corev1.Event) error {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(event)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.Resource(eventsResource).Namespace(event.Namespace).Create(&unstructured.Unstructured{Object: obj}, metav1.CreateOptions{})
+	return err
+}
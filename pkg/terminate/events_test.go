@@ -0,0 +1,206 @@
+package terminate
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestRecordEvents(t *testing.T) {
+
+	// given
+	log := logger.NewLogger(os.Stdout, 0)
+	listEvents := func(t *testing.T, recorder *EventRecorder, namespace string) []corev1.Event {
+		list, err := recorder.client.Resource(eventsResource).Namespace(namespace).List(metav1.ListOptions{})
+		require.NoError(t, err)
+		events := []corev1.Event{}
+		for _, item := range list.Items {
+			e := corev1.Event{}
+			err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &e)
+			require.NoError(t, err)
+			events = append(events, e)
+		}
+		return events
+	}
+
+	t.Run("terminated resource", func(t *testing.T) {
+		// given
+		kubeconfig, server := setup(t)
+		defer server.Close()
+		recorder := &EventRecorder{
+			User:   "jdoe",
+			client: fake.NewSimpleDynamicClient(runtime.NewScheme()),
+		}
+		metadata := []ResourceMetadata{
+			{
+				Kind: "pod",
+				Name: "cookie",
+			},
+			{
+				Kind: "deploy",
+				Name: "latte", // not being deleted, so not terminated
+			},
+		}
+		// when
+		_, err := Terminate(metadata, kubeconfig, Options{EventRecorder: recorder}, log)
+		// then
+		require.NoError(t, err)
+		events := listEvents(t, recorder, "default")
+		require.Len(t, events, 2)
+		byKind := map[string]corev1.Event{}
+		for _, e := range events {
+			assert.Equal(t, "default", e.Namespace)
+			assert.Equal(t, EventReason, e.Reason)
+			assert.Equal(t, corev1.EventTypeWarning, e.Type)
+			assert.Equal(t, EventComponent, e.Source.Component)
+			assert.Equal(t, int32(1), e.Count)
+			assert.False(t, e.FirstTimestamp.IsZero())
+			byKind[e.InvolvedObject.Kind] = e
+		}
+		require.Contains(t, byKind, "Pod")
+		assert.Equal(t, "v1", byKind["Pod"].InvolvedObject.APIVersion)
+		assert.Equal(t, "default", byKind["Pod"].InvolvedObject.Namespace)
+		assert.Equal(t, "cookie", byKind["Pod"].InvolvedObject.Name)
+		assert.Equal(t, "Finalizers [cheesecake] forcefully removed by jdoe with kubectl-terminate", byKind["Pod"].Message)
+		require.Contains(t, byKind, "Namespace")
+		assert.Equal(t, "default", byKind["Namespace"].InvolvedObject.Name)
+		assert.Equal(t, `Finalizers [cheesecake] forcefully removed from pod "cookie" by jdoe with kubectl-terminate`, byKind["Namespace"].Message)
+	})
+
+	t.Run("resource with the same name as its namespace", func(t *testing.T) {
+		// given
+		recorder := &EventRecorder{
+			User:   "jdoe",
+			client: fake.NewSimpleDynamicClient(runtime.NewScheme()),
+		}
+		r := &unstructured.Unstructured{}
+		r.SetAPIVersion("example.com/v1")
+		r.SetKind("Database")
+		r.SetNamespace("orders")
+		r.SetName("orders")
+		out := &bytes.Buffer{}
+		// when
+		recorder.record("database", r, []string{"example.com/cleanup"}, logger.NewLogger(out, 0))
+		// then
+		assert.Empty(t, out.String()) // no warning
+		events := listEvents(t, recorder, "orders")
+		require.Len(t, events, 2)
+		assert.NotEqual(t, events[0].Name, events[1].Name)
+		kinds := []string{events[0].InvolvedObject.Kind, events[1].InvolvedObject.Kind}
+		assert.ElementsMatch(t, []string{"Database", "Namespace"}, kinds)
+	})
+
+	t.Run("resource in terminating namespace", func(t *testing.T) {
+		// given
+		ns := &unstructured.Unstructured{}
+		ns.SetAPIVersion("v1")
+		ns.SetKind("Namespace")
+		ns.SetName("pasta")
+		deletionTimestamp := metav1.Now()
+		ns.SetDeletionTimestamp(&deletionTimestamp)
+		recorder := &EventRecorder{
+			User:   "jdoe",
+			client: fake.NewSimpleDynamicClient(runtime.NewScheme(), ns),
+		}
+		r := &unstructured.Unstructured{}
+		r.SetAPIVersion("v1")
+		r.SetKind("Pod")
+		r.SetNamespace("pasta")
+		r.SetName("penne")
+		out := &bytes.Buffer{}
+		// when
+		recorder.record("pod", r, []string{"cheesecake"}, logger.NewLogger(out, 0))
+		// then
+		assert.Empty(t, out.String()) // no warning
+		assert.Empty(t, listEvents(t, recorder, "pasta"))
+		// only the event on the namespace is recorded, in the 'default' namespace (as for cluster-scoped resources)
+		events := listEvents(t, recorder, "default")
+		require.Len(t, events, 1)
+		assert.Equal(t, corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: "pasta"}, events[0].InvolvedObject)
+		assert.Equal(t, `Finalizers [cheesecake] forcefully removed from pod "penne" by jdoe with kubectl-terminate`, events[0].Message)
+	})
+
+	t.Run("with kubernetes user", func(t *testing.T) {
+		// given
+		recorder := &EventRecorder{
+			User:           "jdoe",
+			KubernetesUser: "admin",
+			client:         fake.NewSimpleDynamicClient(runtime.NewScheme()),
+		}
+		r := &unstructured.Unstructured{}
+		r.SetAPIVersion("example.com/v1")
+		r.SetKind("Cake")
+		r.SetName("cheesecake")
+		// when
+		recorder.record("cake", r, []string{"example.com/cleanup"}, log)
+		// then
+		events := listEvents(t, recorder, "default")
+		require.Len(t, events, 1)
+		assert.Equal(t, "", events[0].InvolvedObject.Namespace)
+		assert.Equal(t, "Finalizers [example.com/cleanup] forcefully removed by jdoe as admin with kubectl-terminate", events[0].Message)
+	})
+
+	t.Run("no event in dry-run", func(t *testing.T) {
+		// given
+		kubeconfig, server := setup(t)
+		defer server.Close()
+		metadata := []ResourceMetadata{
+			{
+				Kind: "pod",
+				Name: "cookie",
+			},
+		}
+		for _, dryRun := range []DryRunStrategy{DryRunClient, DryRunServer} {
+			recorder := &EventRecorder{
+				User:   "jdoe",
+				client: fake.NewSimpleDynamicClient(runtime.NewScheme()),
+			}
+			// when
+			_, err := Terminate(metadata, kubeconfig, Options{EventRecorder: recorder, DryRun: dryRun}, log)
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, listEvents(t, recorder, "default"))
+		}
+	})
+
+	t.Run("failed event", func(t *testing.T) {
+		// given
+		kubeconfig, server := setup(t)
+		defer server.Close()
+		client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+		client.PrependReactor("create", "events", func(action clienttesting.Action) (bool, runtime.Object, error) {
+			return true, nil, fmt.Errorf("mock error")
+		})
+		recorder := &EventRecorder{
+			User:   "jdoe",
+			client: client,
+		}
+		metadata := []ResourceMetadata{
+			{
+				Kind: "pod",
+				Name: "cookie",
+			},
+		}
+		out := &bytes.Buffer{}
+		// when
+		results, err := Terminate(metadata, kubeconfig, Options{EventRecorder: recorder}, logger.NewLogger(out, 0))
+		// then
+		require.NoError(t, err) // the resource was terminated anyways
+		require.Len(t, results, 1)
+		assert.Equal(t, StatusTerminated, results[0].Status)
+		assert.Contains(t, out.String(), `WARNING: unable to record event on pod "cookie": mock error`)
+		assert.Contains(t, out.String(), `WARNING: unable to record event on namespace "default": mock error`)
+	})
+}
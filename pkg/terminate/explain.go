@@ -0,0 +1,271 @@
+package terminate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// OwnerStatus the status of the owner of a resource
+type OwnerStatus string
+
+const (
+	// OwnerExists the owner exists and is not being deleted
+	OwnerExists OwnerStatus = "exists"
+	// OwnerBeingDeleted the owner exists and is being deleted
+	OwnerBeingDeleted OwnerStatus = "being deleted"
+	// OwnerNotFound the owner does not exist (anymore)
+	OwnerNotFound OwnerStatus = "not found"
+	// OwnerUnknown the status of the owner could not be determined (eg: its type is unknown)
+	OwnerUnknown OwnerStatus = "unknown"
+)
+
+// Owner an owner of a resource
+type Owner struct {
+	Reference metav1.OwnerReference
+	Status    OwnerStatus
+}
+
+// Explanation the reasons why a resource is stuck
+type Explanation struct {
+	Kind                       string
+	Namespace                  string
+	Name                       string
+	DeletionTimestamp          *metav1.Time
+	DeletionGracePeriodSeconds *int64
+	Finalizers                 []string
+	// SpecFinalizers the finalizers in the spec of a namespace
+	SpecFinalizers []string
+	Owners         []Owner
+	// Conditions the conditions of a namespace
+	Conditions []corev1.NamespaceCondition
+	// RemainingResources the resources which still hold finalizers in a namespace
+	RemainingResources []FinalizedResource
+	// Suggestions the `terminate` commands to run
+	Suggestions []string
+}
+
+// Explain inspects the resource with the given type and name (or all the resources matching the given selectors)
+// and reports why it is stuck, without modifying it
+func Explain(metadata []ResourceMetadata, kubeconfig clientcmd.ClientConfig, log logger.Logger) ([]Explanation, error) {
+	discoveryClient, err := newDiscoveryClient(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	resolver := newAPIResourceResolver(discoveryClient)
+	explanations := []Explanation{}
+	for _, m := range metadata {
+		log.Debug("loading API resource")
+		apiresource, err := resolver.resolve(m.Kind, log)
+		if err != nil {
+			return explanations, err
+		}
+		log.Debug("initializing client")
+		cl, err := newResourceClient(kubeconfig, m.Namespace, apiresource)
+		if err != nil {
+			return explanations, err
+		}
+		resources, err := loadResources(cl, m, log)
+		if err != nil {
+			return explanations, err
+		}
+		if len(resources) == 0 {
+			log.Info("No resources found")
+			continue
+		}
+		for _, resource := range resources {
+			e, err := explainResource(kubeconfig, discoveryClient, resolver, apiresource, m.Kind, resource, log)
+			if err != nil {
+				return explanations, err
+			}
+			printExplanation(e, log)
+			explanations = append(explanations, e)
+		}
+	}
+	return explanations, nil
+}
+
+func explainResource(kubeconfig clientcmd.ClientConfig, discoveryClient discovery.DiscoveryInterface, resolver *apiResourceResolver, apiresource metav1.APIResource, kind string, resource *unstructured.Unstructured, log logger.Logger) (Explanation, error) {
+	e := Explanation{
+		Kind:                       kind,
+		Namespace:                  resource.GetNamespace(),
+		Name:                       resource.GetName(),
+		DeletionTimestamp:          resource.GetDeletionTimestamp(),
+		DeletionGracePeriodSeconds: resource.GetDeletionGracePeriodSeconds(),
+		Finalizers:                 resource.GetFinalizers(),
+	}
+	for _, ref := range resource.GetOwnerReferences() {
+		e.Owners = append(e.Owners, Owner{
+			Reference: ref,
+			Status:    ownerStatus(kubeconfig, resolver, resource.GetNamespace(), ref, log),
+		})
+	}
+	if isNamespace(apiresource) {
+		specFinalizers, _, err := unstructured.NestedStringSlice(resource.Object, "spec", "finalizers")
+		if err != nil {
+			return e, err
+		}
+		e.SpecFinalizers = specFinalizers
+		conditions, _, err := unstructured.NestedSlice(resource.Object, "status", "conditions")
+		if err != nil {
+			return e, err
+		}
+		for _, c := range conditions {
+			condition := corev1.NamespaceCondition{}
+			if c, ok := c.(map[string]interface{}); ok {
+				if err := runtime.DefaultUnstructuredConverter.FromUnstructured(c, &condition); err != nil {
+					return e, err
+				}
+			}
+			e.Conditions = append(e.Conditions, condition)
+		}
+		log.Debug("looking up the remaining resources in namespace '%s'", resource.GetName())
+		apiresources, err := listableResources(discoveryClient, true, log)
+		if err != nil {
+			return e, err
+		}
+		dynamicClient, err := newDynamicClient(kubeconfig)
+		if err != nil {
+			return e, err
+		}
+		e.RemainingResources = findFinalizedResources(dynamicClient, apiresources, resource.GetName(), log)
+	}
+	e.Suggestions = suggestions(apiresource, e)
+	return e, nil
+}
+
+// ownerStatus returns the status of the owner with the given reference
+func ownerStatus(kubeconfig clientcmd.ClientConfig, resolver *apiResourceResolver, namespace string, ref metav1.OwnerReference, log logger.Logger) OwnerStatus {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return OwnerUnknown
+	}
+	ownerType := strings.ToLower(ref.Kind)
+	if gv.Group != "" {
+		ownerType = ownerType + "." + gv.Group
+	}
+	apiresource, err := resolver.resolve(ownerType, log)
+	if err != nil {
+		log.Debug("unable to lookup owner type '%s': %v", ownerType, err)
+		return OwnerUnknown
+	}
+	cl, err := newResourceClient(kubeconfig, namespace, apiresource)
+	if err != nil {
+		return OwnerUnknown
+	}
+	owner, err := cl.Get(ref.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return OwnerNotFound
+	} else if err != nil {
+		log.Debug("unable to get owner '%s/%s': %v", ownerType, ref.Name, err)
+		return OwnerUnknown
+	}
+	if owner.GetUID() != ref.UID {
+		return OwnerNotFound // another resource with the same name
+	}
+	if owner.GetDeletionTimestamp() != nil {
+		return OwnerBeingDeleted
+	}
+	return OwnerExists
+}
+
+// suggestions returns the `terminate` commands to run for the explained resource
+func suggestions(apiresource metav1.APIResource, e Explanation) []string {
+	if e.DeletionTimestamp == nil {
+		return nil // not stuck
+	}
+	result := []string{}
+	// first, terminate the remaining resources in the namespace, grouped by type
+	names := map[string][]string{}
+	types := []string{}
+	for _, r := range e.RemainingResources {
+		if r.DeletionTimestamp == nil {
+			continue // not being deleted, so the finalizers are not blocking
+		}
+		t := typeName(r.APIResource)
+		if _, exists := names[t]; !exists {
+			types = append(types, t)
+		}
+		names[t] = append(names[t], r.Name)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		result = append(result, fmt.Sprintf("kubectl terminate -n %s %s %s", e.Name, t, strings.Join(names[t], " ")))
+	}
+	// then, terminate the resource itself
+	if len(e.Finalizers) > 0 || len(e.SpecFinalizers) > 0 {
+		if apiresource.Namespaced {
+			result = append(result, fmt.Sprintf("kubectl terminate -n %s %s/%s", e.Namespace, typeName(apiresource), e.Name))
+		} else {
+			result = append(result, fmt.Sprintf("kubectl terminate %s/%s", typeName(apiresource), e.Name))
+		}
+	}
+	return result
+}
+
+func printExplanation(e Explanation, log logger.Logger) {
+	if e.Namespace != "" {
+		log.Info("%s \"%s\" in namespace \"%s\"", e.Kind, e.Name, e.Namespace)
+	} else {
+		log.Info("%s \"%s\"", e.Kind, e.Name)
+	}
+	if e.DeletionTimestamp == nil {
+		log.Info("  deletion timestamp: none (not being deleted)")
+	} else if e.DeletionGracePeriodSeconds != nil {
+		log.Info("  deletion timestamp: %s (grace period: %ds)", e.DeletionTimestamp.UTC().Format("2006-01-02T15:04:05Z"), *e.DeletionGracePeriodSeconds)
+	} else {
+		log.Info("  deletion timestamp: %s", e.DeletionTimestamp.UTC().Format("2006-01-02T15:04:05Z"))
+	}
+	log.Info("  finalizers: %s", listOrNone(e.Finalizers))
+	if e.SpecFinalizers != nil {
+		log.Info("  spec finalizers: %s", listOrNone(e.SpecFinalizers))
+	}
+	if len(e.Owners) == 0 {
+		log.Info("  owners: none")
+	} else {
+		log.Info("  owners:")
+		for _, o := range e.Owners {
+			blocking := ""
+			if o.Reference.BlockOwnerDeletion != nil && *o.Reference.BlockOwnerDeletion {
+				blocking = ", blocking owner deletion"
+			}
+			log.Info("    - %s/%s (%s%s)", o.Reference.Kind, o.Reference.Name, o.Status, blocking)
+		}
+	}
+	if len(e.Conditions) > 0 {
+		log.Info("  conditions:")
+		for _, c := range e.Conditions {
+			log.Info("    - %s=%s: %s", c.Type, c.Status, c.Message)
+		}
+	}
+	if len(e.RemainingResources) > 0 {
+		log.Info("  remaining resources with finalizers:")
+		for _, r := range e.RemainingResources {
+			log.Info("    - %s/%s: %s", typeName(r.APIResource), r.Name, listOrNone(r.Finalizers))
+		}
+	}
+	if len(e.Suggestions) > 0 {
+		log.Info("  suggested commands:")
+		for _, s := range e.Suggestions {
+			log.Info("    %s", s)
+		}
+	}
+}
+
+func listOrNone(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	return strings.Join(values, ", ")
+}
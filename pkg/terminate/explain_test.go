@@ -0,0 +1,164 @@
+package terminate
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+	"github.com/xcoulon/kubectl-terminate/test"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExplain(t *testing.T) {
+
+	// given
+	log := logger.NewLogger(os.Stdout, 0)
+
+	t.Run("namespace", func(t *testing.T) {
+		// given
+		kubeconfig, server := setup(t)
+		defer server.Close()
+		// when
+		explanations, err := Explain([]ResourceMetadata{
+			{
+				Kind: "namespace",
+				Name: "pasta",
+			},
+		}, kubeconfig, log)
+		// then
+		require.NoError(t, err)
+		require.Len(t, explanations, 1)
+		e := explanations[0]
+		assert.True(t, test.DeletionTimestamp.Equal(e.DeletionTimestamp))
+		assert.Empty(t, e.Finalizers)
+		assert.Equal(t, []string{"kubernetes"}, e.SpecFinalizers)
+		require.Len(t, e.Conditions, 1)
+		assert.Equal(t, corev1.NamespaceFinalizersRemaining, e.Conditions[0].Type)
+		require.Len(t, e.RemainingResources, 1)
+		assert.Equal(t, "pods", e.RemainingResources[0].APIResource.Name)
+		assert.Equal(t, "penne", e.RemainingResources[0].Name)
+		assert.Equal(t, []string{"cheesecake"}, e.RemainingResources[0].Finalizers)
+		assert.Equal(t, []string{
+			"kubectl terminate -n pasta pods penne",
+			"kubectl terminate namespaces/pasta",
+		}, e.Suggestions)
+	})
+
+	t.Run("pod with missing owner", func(t *testing.T) {
+		// given
+		kubeconfig, server := setup(t)
+		defer server.Close()
+		// when
+		explanations, err := Explain([]ResourceMetadata{
+			{
+				Kind:      "pod",
+				Namespace: "pasta",
+				Name:      "penne",
+			},
+		}, kubeconfig, log)
+		// then
+		require.NoError(t, err)
+		require.Len(t, explanations, 1)
+		e := explanations[0]
+		require.Len(t, e.Owners, 1)
+		assert.Equal(t, "rigatoni", e.Owners[0].Reference.Name)
+		assert.Equal(t, OwnerNotFound, e.Owners[0].Status)
+		assert.Nil(t, e.RemainingResources)
+		assert.Equal(t, []string{
+			"kubectl terminate -n pasta pods/penne",
+		}, e.Suggestions)
+	})
+
+	t.Run("deployment not being deleted", func(t *testing.T) {
+		// given
+		kubeconfig, server := setup(t)
+		defer server.Close()
+		// when
+		explanations, err := Explain([]ResourceMetadata{
+			{
+				Kind: "deployment",
+				Name: "latte",
+			},
+		}, kubeconfig, log)
+		// then
+		require.NoError(t, err)
+		require.Len(t, explanations, 1)
+		assert.Nil(t, explanations[0].DeletionTimestamp)
+		assert.Empty(t, explanations[0].Suggestions)
+	})
+}
+
+func TestSuggestions(t *testing.T) {
+
+	// given
+	pods := metav1.APIResource{
+		Version:    "v1",
+		Name:       "pods",
+		Namespaced: true,
+	}
+	customtypes := metav1.APIResource{
+		Group:      "customdomain",
+		Version:    "v1beta1",
+		Name:       "customtypes",
+		Namespaced: true,
+	}
+	namespaces := metav1.APIResource{
+		Version: "v1",
+		Name:    "namespaces",
+		Kind:    "Namespace",
+	}
+
+	t.Run("namespace with remaining resources", func(t *testing.T) {
+		// when
+		s := suggestions(namespaces, Explanation{
+			Name:              "pasta",
+			DeletionTimestamp: &test.DeletionTimestamp,
+			SpecFinalizers:    []string{"kubernetes"},
+			RemainingResources: []FinalizedResource{
+				{
+					APIResource:       pods,
+					Namespace:         "pasta",
+					Name:              "penne",
+					DeletionTimestamp: &test.DeletionTimestamp,
+				},
+				{
+					APIResource:       customtypes,
+					Namespace:         "pasta",
+					Name:              "fusilli",
+					DeletionTimestamp: &test.DeletionTimestamp,
+				},
+				{
+					APIResource: pods,
+					Namespace:   "pasta",
+					Name:        "linguine", // not being deleted
+				},
+				{
+					APIResource:       pods,
+					Namespace:         "pasta",
+					Name:              "farfalle",
+					DeletionTimestamp: &test.DeletionTimestamp,
+				},
+			},
+		})
+		// then
+		assert.Equal(t, []string{
+			"kubectl terminate -n pasta customtypes.customdomain fusilli",
+			"kubectl terminate -n pasta pods penne farfalle",
+			"kubectl terminate namespaces/pasta",
+		}, s)
+	})
+
+	t.Run("resource not being deleted", func(t *testing.T) {
+		// when
+		s := suggestions(pods, Explanation{
+			Namespace:  "pasta",
+			Name:       "penne",
+			Finalizers: []string{"cheesecake"},
+		})
+		// then
+		assert.Empty(t, s)
+	})
+}
@@ -0,0 +1,65 @@
+package terminate
+
+import (
+	"regexp"
+	"strings"
+)
+
+// finalizerFilter selects the finalizers to remove on a resource.
+// Patterns may contain `*` (any sequence of characters) and `?` (any single character) wildcards,
+// eg: `*.argoproj.io`
+type finalizerFilter struct {
+	// remove the patterns of the finalizers to remove (all finalizers if empty)
+	remove []string
+	// keep the patterns of the finalizers to keep, which take precedence over the ones to remove
+	keep []string
+}
+
+func newFinalizerFilter(opts Options) finalizerFilter {
+	return finalizerFilter{
+		remove: opts.Finalizers,
+		keep:   opts.KeepFinalizers,
+	}
+}
+
+// selective returns 'true' if only some finalizers may be removed
+func (f finalizerFilter) selective() bool {
+	return len(f.remove) > 0 || len(f.keep) > 0
+}
+
+// split returns the finalizers to remove and the finalizers to keep, in their original order
+func (f finalizerFilter) split(finalizers []string) ([]string, []string) {
+	removed := []string{}
+	kept := []string{}
+	for _, finalizer := range finalizers {
+		if f.removes(finalizer) {
+			removed = append(removed, finalizer)
+			continue
+		}
+		kept = append(kept, finalizer)
+	}
+	return removed, kept
+}
+
+// removes returns 'true' if the given finalizer should be removed
+func (f finalizerFilter) removes(finalizer string) bool {
+	return (len(f.remove) == 0 || matchAny(f.remove, finalizer)) && !matchAny(f.keep, finalizer)
+}
+
+func matchAny(patterns []string, finalizer string) bool {
+	for _, p := range patterns {
+		if matchPattern(p, finalizer) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern returns 'true' if the given finalizer matches the given glob pattern.
+// Unlike `path.Match`, the `*` wildcard also matches the `/` character (eg: `*.example.com/*`)
+func matchPattern(pattern, finalizer string) bool {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	return regexp.MustCompile("^" + expr + "$").MatchString(finalizer)
+}
@@ -0,0 +1,73 @@
+package terminate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFinalizerFilter(t *testing.T) {
+
+	// given
+	finalizers := []string{
+		"foregroundDeletion",
+		"kubernetes.io/pvc-protection",
+		"foo.example.com/cleanup",
+		"resources-finalizer.argocd.argoproj.io",
+	}
+
+	t.Run("no filter", func(t *testing.T) {
+		// when
+		removed, kept := finalizerFilter{}.split(finalizers)
+		// then
+		assert.Equal(t, finalizers, removed)
+		assert.Empty(t, kept)
+	})
+
+	t.Run("finalizers to remove", func(t *testing.T) {
+		// when
+		removed, kept := finalizerFilter{
+			remove: []string{"foo.example.com/cleanup", "*.argoproj.io"},
+		}.split(finalizers)
+		// then
+		assert.Equal(t, []string{"foo.example.com/cleanup", "resources-finalizer.argocd.argoproj.io"}, removed)
+		assert.Equal(t, []string{"foregroundDeletion", "kubernetes.io/pvc-protection"}, kept)
+	})
+
+	t.Run("finalizers to keep", func(t *testing.T) {
+		// when
+		removed, kept := finalizerFilter{
+			keep: []string{"kubernetes.io/*", "foregroundDeletion"},
+		}.split(finalizers)
+		// then
+		assert.Equal(t, []string{"foo.example.com/cleanup", "resources-finalizer.argocd.argoproj.io"}, removed)
+		assert.Equal(t, []string{"foregroundDeletion", "kubernetes.io/pvc-protection"}, kept)
+	})
+
+	t.Run("finalizers to remove and to keep", func(t *testing.T) {
+		// when
+		removed, kept := finalizerFilter{
+			remove: []string{"*"},
+			keep:   []string{"*.io*"},
+		}.split(finalizers)
+		// then
+		assert.Equal(t, []string{"foregroundDeletion", "foo.example.com/cleanup"}, removed)
+		assert.Equal(t, []string{"kubernetes.io/pvc-protection", "resources-finalizer.argocd.argoproj.io"}, kept)
+	})
+}
+
+func TestMatchPattern(t *testing.T) {
+
+	t.Run("match", func(t *testing.T) {
+		assert.True(t, matchPattern("foo.example.com/cleanup", "foo.example.com/cleanup"))
+		assert.True(t, matchPattern("*.argoproj.io", "resources-finalizer.argocd.argoproj.io"))
+		assert.True(t, matchPattern("*", "foo.example.com/cleanup"))
+		assert.True(t, matchPattern("kubernetes.io/p?-protection", "kubernetes.io/pv-protection"))
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		assert.False(t, matchPattern("foo.example.com", "foo.example.com/cleanup"))
+		assert.False(t, matchPattern("*.argoproj.io", "argoproj.io"))
+		assert.False(t, matchPattern("foo.example.com/clean?p", "fooXexample.com/cleanup"))
+	})
+}
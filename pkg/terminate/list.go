@@ -0,0 +1,213 @@
+package terminate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+// List returns the resources which are being deleted for longer than the given duration, in the given namespace
+// (or in all namespaces, including the cluster-scoped resources, if empty), among all the resource types which can be listed.
+// The finalizers of the namespaces include the ones in their spec. The resources are not modified.
+func List(kubeconfig clientcmd.ClientConfig, namespace string, olderThan time.Duration, log logger.Logger) ([]FinalizedResource, error) {
+	discoveryClient, err := newDiscoveryClient(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	apiresources, err := listableResources(discoveryClient, namespace != "", log)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := newDynamicClient(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(-olderThan)
+	result := []FinalizedResource{}
+	walkResources(dynamicClient, apiresources, namespace, func(apiresource metav1.APIResource, item unstructured.Unstructured) {
+		ts := item.GetDeletionTimestamp()
+		if ts == nil || ts.Time.After(deadline) {
+			return
+		}
+		finalizers := item.GetFinalizers()
+		if isNamespace(apiresource) {
+			specFinalizers, _, _ := unstructured.NestedStringSlice(item.Object, "spec", "finalizers")
+			finalizers = append(finalizers, specFinalizers...)
+		}
+		result = append(result, FinalizedResource{
+			APIResource:       apiresource,
+			Namespace:         item.GetNamespace(),
+			Name:              item.GetName(),
+			Finalizers:        finalizers,
+			DeletionTimestamp: ts,
+		})
+	}, log)
+	// sort by namespace (with the cluster-scoped resources first), type and name
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		if typeName(result[i].APIResource) != typeName(result[j].APIResource) {
+			return typeName(result[i].APIResource) < typeName(result[j].APIResource)
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// StuckRecord the machine-readable description of a resource which is stuck in deletion
+type StuckRecord struct {
+	Group             string   `json:"group"`
+	Version           string   `json:"version"`
+	Resource          string   `json:"resource"`
+	Namespace         string   `json:"namespace,omitempty"`
+	Name              string   `json:"name"`
+	Finalizers        []string `json:"finalizers"`
+	DeletionTimestamp string   `json:"deletionTimestamp"`
+}
+
+// NewStuckRecord returns the record of the given resource
+func NewStuckRecord(r FinalizedResource) StuckRecord {
+	record := StuckRecord{
+		Group:      r.APIResource.Group,
+		Version:    r.APIResource.Version,
+		Resource:   r.APIResource.Name,
+		Namespace:  r.Namespace,
+		Name:       r.Name,
+		Finalizers: r.Finalizers,
+	}
+	if record.Finalizers == nil {
+		record.Finalizers = []string{}
+	}
+	if r.DeletionTimestamp != nil {
+		record.DeletionTimestamp = r.DeletionTimestamp.UTC().Format(time.RFC3339)
+	}
+	return record
+}
+
+// PrintStuckResources prints the given resources in the given format. By default, the resources are grouped by namespace
+// and by finalizer (a resource with multiple finalizers appears in each group), along with the time since their deletion.
+// With the `name` format, the names do not include the namespace of the resources, so the output can only be given
+// to `kubectl terminate -f -` with the same namespace as the one in which they were listed.
+func PrintStuckResources(out io.Writer, format OutputFormat, resources []FinalizedResource, now time.Time) error {
+	records := make([]StuckRecord, len(resources))
+	for i, r := range resources {
+		records[i] = NewStuckRecord(r)
+	}
+	switch format {
+	case OutputNone:
+		return printStuckResourceGroups(out, resources, now)
+	case OutputJSON:
+		data, err := json.MarshalIndent(records, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	case OutputYAML:
+		data, err := yaml.Marshal(records)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(data)
+		return err
+	case OutputName:
+		for _, r := range resources {
+			if _, err := fmt.Fprintln(out, stuckResourceName(r)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case OutputWide:
+		w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+		fmt.Fprintln(w, "NAMESPACE\tNAME\tRESOURCE\tFINALIZERS\tDELETION TIMESTAMP\tAGE") // nolint: errcheck
+		for i, r := range resources {
+			record := records[i]
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", // nolint: errcheck
+				valueOrNone(record.Namespace),
+				stuckResourceName(r),
+				record.Resource+"."+record.Version+"."+record.Group,
+				valueOrNone(strings.Join(record.Finalizers, ",")),
+				record.DeletionTimestamp,
+				deletionAge(r, now))
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unsupported output format: '%s'", format)
+	}
+}
+
+// printStuckResourceGroups prints the given (sorted) resources grouped by namespace and by finalizer
+func printStuckResourceGroups(out io.Writer, resources []FinalizedResource, now time.Time) error {
+	if len(resources) == 0 {
+		_, err := fmt.Fprintln(out, "No resources found")
+		return err
+	}
+	namespaces := []string{}
+	groups := map[string]map[string][]FinalizedResource{}
+	for _, r := range resources {
+		if _, found := groups[r.Namespace]; !found {
+			namespaces = append(namespaces, r.Namespace)
+			groups[r.Namespace] = map[string][]FinalizedResource{}
+		}
+		if len(r.Finalizers) == 0 {
+			groups[r.Namespace][""] = append(groups[r.Namespace][""], r)
+		}
+		for _, f := range r.Finalizers {
+			groups[r.Namespace][f] = append(groups[r.Namespace][f], r)
+		}
+	}
+	for _, ns := range namespaces {
+		if ns == "" {
+			fmt.Fprintln(out, "cluster-scoped resources:") // nolint: errcheck
+		} else {
+			fmt.Fprintf(out, "namespace \"%s\":\n", ns) // nolint: errcheck
+		}
+		finalizers := make([]string, 0, len(groups[ns]))
+		for f := range groups[ns] {
+			finalizers = append(finalizers, f)
+		}
+		sort.Strings(finalizers) // resources without finalizers first
+		for _, f := range finalizers {
+			if f == "" {
+				fmt.Fprintln(out, "  no finalizers:") // nolint: errcheck
+			} else {
+				fmt.Fprintf(out, "  finalizer \"%s\":\n", f) // nolint: errcheck
+			}
+			for _, r := range groups[ns][f] {
+				if _, err := fmt.Fprintf(out, "    %s (deleted %s ago)\n", stuckResourceName(r), deletionAge(r, now)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// stuckResourceName returns the name of the resource in the `TYPE/NAME` form, eg: `pod/cookie` or `deployment.apps/latte`
+func stuckResourceName(r FinalizedResource) string {
+	if r.APIResource.Group == "" {
+		return strings.ToLower(r.APIResource.Kind) + "/" + r.Name
+	}
+	return strings.ToLower(r.APIResource.Kind) + "." + r.APIResource.Group + "/" + r.Name
+}
+
+// deletionAge returns the time elapsed since the deletion of the given resource, eg: `12m` or `3d`
+func deletionAge(r FinalizedResource, now time.Time) string {
+	if r.DeletionTimestamp == nil {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(now.Sub(r.DeletionTimestamp.Time))
+}
@@ -0,0 +1,162 @@
+package terminate
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+	"github.com/xcoulon/kubectl-terminate/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestList(t *testing.T) {
+
+	// given
+	log := logger.NewLogger(os.Stdout, 0)
+	kubeconfig, server := setup(t)
+	defer server.Close()
+
+	t.Run("in namespace", func(t *testing.T) {
+		// when
+		resources, err := List(kubeconfig, "pasta", 10*time.Minute, log)
+		// then
+		require.NoError(t, err)
+		require.Len(t, resources, 1)
+		assert.Equal(t, "pods", resources[0].APIResource.Name)
+		assert.Equal(t, "pasta", resources[0].Namespace)
+		assert.Equal(t, "penne", resources[0].Name)
+		assert.Equal(t, []string{"cheesecake"}, resources[0].Finalizers)
+	})
+
+	t.Run("in all namespaces", func(t *testing.T) {
+		// when
+		resources, err := List(kubeconfig, "", 10*time.Minute, log)
+		// then
+		require.NoError(t, err)
+		names := []string{}
+		for _, r := range resources {
+			names = append(names, stuckResourceName(r))
+		}
+		// the `default` namespace is not being deleted
+		assert.Equal(t, []string{"namespace/pasta", "pod/cookie", "pod/fudge", "pod/penne"}, names)
+		assert.Equal(t, []string{"kubernetes"}, resources[0].Finalizers) // spec finalizers of the namespace
+		assert.Empty(t, resources[2].Finalizers)
+	})
+
+	t.Run("not deleted for long enough", func(t *testing.T) {
+		// when
+		resources, err := List(kubeconfig, "", time.Since(test.DeletionTimestamp.Time)+time.Hour, log)
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, resources)
+	})
+}
+
+func TestPrintStuckResources(t *testing.T) {
+
+	// given
+	deletionTimestamp := metav1.Date(2020, time.March, 1, 12, 0, 0, 0, time.UTC)
+	now := deletionTimestamp.Add(90 * time.Minute)
+	pods := metav1.APIResource{Version: "v1", Name: "pods", Kind: "Pod", Namespaced: true}
+	resources := []FinalizedResource{
+		{
+			APIResource:       metav1.APIResource{Version: "v1", Name: "namespaces", Kind: "Namespace"},
+			Name:              "pasta",
+			Finalizers:        []string{"kubernetes"},
+			DeletionTimestamp: &deletionTimestamp,
+		},
+		{
+			APIResource:       pods,
+			Namespace:         "default",
+			Name:              "cookie",
+			Finalizers:        []string{"cheesecake", "example.com/protection"},
+			DeletionTimestamp: &deletionTimestamp,
+		},
+		{
+			APIResource:       pods,
+			Namespace:         "default",
+			Name:              "fudge",
+			DeletionTimestamp: &deletionTimestamp,
+		},
+		{
+			APIResource:       metav1.APIResource{Group: "apps", Version: "v1", Name: "deployments", Kind: "Deployment", Namespaced: true},
+			Namespace:         "default",
+			Name:              "latte",
+			Finalizers:        []string{"cheesecake"},
+			DeletionTimestamp: &deletionTimestamp,
+		},
+	}
+
+	t.Run("grouped by namespace and finalizer", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		// when
+		err := PrintStuckResources(out, OutputNone, resources, now)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, `cluster-scoped resources:
+  finalizer "kubernetes":
+    namespace/pasta (deleted 90m ago)
+namespace "default":
+  no finalizers:
+    pod/fudge (deleted 90m ago)
+  finalizer "cheesecake":
+    pod/cookie (deleted 90m ago)
+    deployment.apps/latte (deleted 90m ago)
+  finalizer "example.com/protection":
+    pod/cookie (deleted 90m ago)
+`, out.String())
+	})
+
+	t.Run("no resources", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		// when
+		err := PrintStuckResources(out, OutputNone, []FinalizedResource{}, now)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "No resources found\n", out.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		// when
+		err := PrintStuckResources(out, OutputJSON, resources[:3], now)
+		// then
+		require.NoError(t, err)
+		assert.JSONEq(t, `[
+			{"group":"","version":"v1","resource":"namespaces","name":"pasta","finalizers":["kubernetes"],"deletionTimestamp":"2020-03-01T12:00:00Z"},
+			{"group":"","version":"v1","resource":"pods","namespace":"default","name":"cookie","finalizers":["cheesecake","example.com/protection"],"deletionTimestamp":"2020-03-01T12:00:00Z"},
+			{"group":"","version":"v1","resource":"pods","namespace":"default","name":"fudge","finalizers":[],"deletionTimestamp":"2020-03-01T12:00:00Z"}
+		]`, out.String())
+	})
+
+	t.Run("name", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		// when
+		err := PrintStuckResources(out, OutputName, resources, now)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "namespace/pasta\npod/cookie\npod/fudge\ndeployment.apps/latte\n", out.String())
+	})
+
+	t.Run("wide", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		// when
+		err := PrintStuckResources(out, OutputWide, resources[1:3], now)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, `NAMESPACE   NAME         RESOURCE   FINALIZERS                          DELETION TIMESTAMP     AGE
+default     pod/cookie   pods.v1.   cheesecake,example.com/protection   2020-03-01T12:00:00Z   90m
+default     pod/fudge    pods.v1.   <none>                              2020-03-01T12:00:00Z   90m
+`, out.String())
+	})
+}
@@ -0,0 +1,46 @@
+package terminate
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// isNamespace returns 'true' if the given API resource is the core `Namespace` type
+func isNamespace(apiresource metav1.APIResource) bool {
+	return apiresource.Group == "" && apiresource.Kind == "Namespace"
+}
+
+// removeSpecFinalizers removes the finalizers selected by the given filter in the `spec.finalizers` of the given namespace.
+// Returns the finalizers that were removed (if any)
+func removeSpecFinalizers(ns *unstructured.Unstructured, filter finalizerFilter) ([]string, error) {
+	finalizers, found, err := unstructured.NestedStringSlice(ns.UnstructuredContent(), "spec", "finalizers")
+	if err != nil {
+		return nil, err
+	}
+	if !found || len(finalizers) == 0 {
+		return nil, nil // do not modify the existing namespace
+	}
+	removed, kept := filter.split(finalizers)
+	if len(removed) == 0 {
+		return nil, nil // do not modify the existing namespace
+	}
+	return removed, unstructured.SetNestedStringSlice(ns.Object, kept, "spec", "finalizers") // override the current value (with an empty slice if all finalizers were removed)
+}
+
+// finalizeNamespace removes the finalizers selected by the given filter in the `spec.finalizers` of the given namespace.
+// Unlike `metadata.finalizers`, this field can only be changed through the `finalize` subresource,
+// i.e., with a `PUT /api/v1/namespaces/{name}/finalize` request.
+// Returns the finalizer fields that were cleared, the finalizers that were removed (if any),
+// and the updated namespace (or the given one if it was not modified)
+func finalizeNamespace(cl dynamic.ResourceInterface, ns *unstructured.Unstructured, filter finalizerFilter, opts metav1.UpdateOptions) ([]string, []string, *unstructured.Unstructured, error) {
+	removed, err := removeSpecFinalizers(ns, filter)
+	if err != nil || len(removed) == 0 {
+		return nil, nil, ns, err
+	}
+	updated, err := cl.Update(ns, opts, "finalize")
+	if err != nil {
+		return nil, nil, ns, err
+	}
+	return []string{"spec.finalizers"}, removed, updated, nil
+}
@@ -0,0 +1,102 @@
+package terminate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestIsNamespace(t *testing.T) {
+
+	t.Run("namespace", func(t *testing.T) {
+		assert.True(t, isNamespace(metav1.APIResource{
+			Version: "v1",
+			Kind:    "Namespace",
+			Name:    "namespaces",
+		}))
+	})
+
+	t.Run("custom resource with namespace kind", func(t *testing.T) {
+		assert.False(t, isNamespace(metav1.APIResource{
+			Group:   "customdomain",
+			Version: "v1beta1",
+			Kind:    "Namespace",
+			Name:    "namespaces",
+		}))
+	})
+
+	t.Run("pod", func(t *testing.T) {
+		assert.False(t, isNamespace(metav1.APIResource{
+			Version: "v1",
+			Kind:    "Pod",
+			Name:    "pods",
+		}))
+	})
+}
+
+func TestRemoveSpecFinalizers(t *testing.T) {
+
+	t.Run("namespace with spec finalizers", func(t *testing.T) {
+		// given
+		ns := newNamespace(t, corev1.FinalizerKubernetes)
+		// when
+		removed, err := removeSpecFinalizers(ns, finalizerFilter{})
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"kubernetes"}, removed)
+		finalizers, found, err := unstructured.NestedStringSlice(ns.Object, "spec", "finalizers")
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Empty(t, finalizers)
+	})
+
+	t.Run("namespace with selected spec finalizers", func(t *testing.T) {
+		// given
+		ns := newNamespace(t, corev1.FinalizerKubernetes, "custom.example.com/cleanup")
+		// when
+		removed, err := removeSpecFinalizers(ns, finalizerFilter{remove: []string{"*.example.com/*"}})
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"custom.example.com/cleanup"}, removed)
+		finalizers, _, err := unstructured.NestedStringSlice(ns.Object, "spec", "finalizers")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"kubernetes"}, finalizers)
+	})
+
+	t.Run("namespace without spec finalizers", func(t *testing.T) {
+		// given
+		ns := newNamespace(t)
+		// when
+		removed, err := removeSpecFinalizers(ns, finalizerFilter{})
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, removed)
+	})
+}
+
+func newNamespace(t *testing.T, finalizers ...corev1.FinalizerName) *unstructured.Unstructured {
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&corev1.Namespace{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Namespace",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "pasta",
+		},
+		Spec: corev1.NamespaceSpec{
+			Finalizers: finalizers,
+		},
+		Status: corev1.NamespaceStatus{
+			Phase: "Terminating",
+		},
+	})
+	require.NoError(t, err)
+	return &unstructured.Unstructured{
+		Object: object,
+	}
+}
@@ -0,0 +1,148 @@
+package terminate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"sigs.k8s.io/yaml"
+)
+
+// OutputFormat the format in which the results are printed
+type OutputFormat string
+
+const (
+	// OutputNone the results are not printed (only the messages during the termination)
+	OutputNone OutputFormat = ""
+	// OutputJSON the results are printed as a JSON array of records
+	OutputJSON OutputFormat = "json"
+	// OutputYAML the results are printed as a YAML list of records
+	OutputYAML OutputFormat = "yaml"
+	// OutputName the results are printed as `TYPE/NAME`, one per line
+	OutputName OutputFormat = "name"
+	// OutputWide the results are printed in a table
+	OutputWide OutputFormat = "wide"
+)
+
+// Record the machine-readable result of the termination of a resource
+type Record struct {
+	Group             string   `json:"group"`
+	Version           string   `json:"version"`
+	Resource          string   `json:"resource"`
+	Namespace         string   `json:"namespace,omitempty"`
+	Name              string   `json:"name"`
+	FinalizersRemoved []string `json:"finalizersRemoved"`
+	DeleteIssued      bool     `json:"deleteIssued"`
+	State             Status   `json:"state"`
+	Reason            string   `json:"reason,omitempty"`
+	Error             string   `json:"error,omitempty"`
+	Backup            string   `json:"backup,omitempty"`
+	// DryRun the dry-run strategy (`none`, `client` or `server`), so that a rehearsal can be told apart from an actual termination
+	DryRun DryRunStrategy `json:"dryRun"`
+}
+
+// NewRecord returns the record of the given result
+func NewRecord(r Result) Record {
+	record := Record{
+		Group:             r.APIResource.Group,
+		Version:           r.APIResource.Version,
+		Resource:          r.APIResource.Name,
+		Namespace:         r.Namespace,
+		Name:              r.Name,
+		FinalizersRemoved: r.Finalizers,
+		DeleteIssued:      r.DeleteIssued,
+		State:             r.Status,
+		Reason:            r.Reason,
+		Backup:            r.Backup,
+		DryRun:            r.DryRun,
+	}
+	if record.DryRun == "" {
+		record.DryRun = DryRunNone
+	}
+	if record.FinalizersRemoved == nil {
+		record.FinalizersRemoved = []string{}
+	}
+	if r.Error != nil {
+		record.Error = r.Error.Error()
+	}
+	return record
+}
+
+// PrintResults prints the given results in the given format
+func PrintResults(out io.Writer, format OutputFormat, results []Result) error {
+	records := make([]Record, len(results))
+	for i, r := range results {
+		records[i] = NewRecord(r)
+	}
+	switch format {
+	case OutputNone:
+		return nil
+	case OutputJSON:
+		data, err := json.MarshalIndent(records, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	case OutputYAML:
+		data, err := yaml.Marshal(records)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(data)
+		return err
+	case OutputName:
+		for i, r := range results {
+			if _, err := fmt.Fprintln(out, qualifiedName(r, records[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	case OutputWide:
+		w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+		fmt.Fprintln(w, "NAMESPACE\tNAME\tRESOURCE\tSTATE\tFINALIZERS REMOVED\tDELETE ISSUED\tERROR") // nolint: errcheck
+		for i, r := range results {
+			record := records[i]
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\t%s\n", // nolint: errcheck
+				valueOrNone(record.Namespace),
+				qualifiedName(r, record),
+				valueOrNone(qualifiedResource(record)),
+				record.State,
+				valueOrNone(strings.Join(record.FinalizersRemoved, ",")),
+				record.DeleteIssued,
+				valueOrNone(record.Error))
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unsupported output format: '%s'", format)
+	}
+}
+
+// qualifiedName returns the name of the resource in the `TYPE/NAME` form, eg: `pod/cookie` or `deployment.apps/latte`,
+// where `TYPE` is the kind given by the user if the API resource type is unknown
+func qualifiedName(r Result, record Record) string {
+	if r.APIResource.Kind == "" {
+		return r.Kind + "/" + r.Name
+	}
+	if record.Group == "" {
+		return strings.ToLower(r.APIResource.Kind) + "/" + r.Name
+	}
+	return strings.ToLower(r.APIResource.Kind) + "." + record.Group + "/" + r.Name
+}
+
+// qualifiedResource returns the fully-qualified resource type of the given record, eg: `pods.v1.` or `deployments.v1.apps`
+func qualifiedResource(record Record) string {
+	if record.Resource == "" {
+		return ""
+	}
+	return record.Resource + "." + record.Version + "." + record.Group
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
@@ -0,0 +1,125 @@
+package terminate
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPrintResults(t *testing.T) {
+
+	// given
+	results := []Result{
+		{
+			APIResource: metav1.APIResource{
+				Version: "v1",
+				Name:    "pods",
+				Kind:    "Pod",
+			},
+			Kind:         "pod",
+			Namespace:    "default",
+			Name:         "cookie",
+			Status:       StatusTerminated,
+			Cleared:      []string{"metadata.finalizers"},
+			Finalizers:   []string{"cheesecake"},
+			DeleteIssued: true,
+		},
+		{
+			APIResource: metav1.APIResource{
+				Group:   "apps",
+				Version: "v1",
+				Name:    "deployments",
+				Kind:    "Deployment",
+			},
+			Kind:      "deploy",
+			Namespace: "default",
+			Name:      "latte",
+			Status:    StatusSkipped,
+			Reason:    "resource 'latte' is not being deleted",
+		},
+		{
+			Kind:   "foo",
+			Name:   "bar",
+			Status: StatusFailed,
+			Error:  fmt.Errorf("unknown resource type: 'foo'"),
+		},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		// when
+		err := PrintResults(out, OutputJSON, results)
+		// then
+		require.NoError(t, err)
+		assert.JSONEq(t, `[
+			{"group":"","version":"v1","resource":"pods","namespace":"default","name":"cookie","finalizersRemoved":["cheesecake"],"deleteIssued":true,"state":"terminated","dryRun":"none"},
+			{"group":"apps","version":"v1","resource":"deployments","namespace":"default","name":"latte","finalizersRemoved":[],"deleteIssued":false,"state":"skipped","reason":"resource 'latte' is not being deleted","dryRun":"none"},
+			{"group":"","version":"","resource":"","name":"bar","finalizersRemoved":[],"deleteIssued":false,"state":"failed","error":"unknown resource type: 'foo'","dryRun":"none"}
+		]`, out.String())
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		// when
+		err := PrintResults(out, OutputYAML, results[:1])
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, `- deleteIssued: true
+  dryRun: none
+  finalizersRemoved:
+  - cheesecake
+  group: ""
+  name: cookie
+  namespace: default
+  resource: pods
+  state: terminated
+  version: v1
+`, out.String())
+	})
+
+	t.Run("name", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		// when
+		err := PrintResults(out, OutputName, results)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "pod/cookie\ndeployment.apps/latte\nfoo/bar\n", out.String())
+	})
+
+	t.Run("wide", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		// when
+		err := PrintResults(out, OutputWide, results)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "NAMESPACE   NAME                    RESOURCE              STATE        FINALIZERS REMOVED   DELETE ISSUED   ERROR\n"+
+			"default     pod/cookie              pods.v1.              terminated   cheesecake           true            <none>\n"+
+			"default     deployment.apps/latte   deployments.v1.apps   skipped      <none>               false           <none>\n"+
+			"<none>      foo/bar                 <none>                failed       <none>               false           unknown resource type: 'foo'\n", out.String())
+	})
+
+	t.Run("none", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		// when
+		err := PrintResults(out, OutputNone, results)
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		// when
+		err := PrintResults(new(bytes.Buffer), OutputFormat("xml"), results)
+		// then
+		require.EqualError(t, err, "unsupported output format: 'xml'")
+	})
+}
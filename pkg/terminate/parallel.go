@@ -0,0 +1,105 @@
+package terminate
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// task a resource to terminate
+type task struct {
+	cl          dynamic.ResourceInterface
+	apiresource metav1.APIResource
+	kind        string
+	namespace   string
+	name        string
+	resource    *unstructured.Unstructured
+	// err the error which occurred while loading the resource (if applicable)
+	err error
+	// declined 'true' if the termination of the resource was not confirmed
+	declined bool
+	// content the resources to terminate before the namespace of this task (if it is terminated with its content)
+	content []task
+}
+
+// outcome the outcome of a task
+type outcome struct {
+	out     bytes.Buffer
+	results []Result
+	err     error
+	done    chan struct{}
+}
+
+// runTasks runs the given func on all tasks, with a pool of `parallel` workers (at least 1).
+// The messages logged by each task are buffered, then printed along with the results in the order of the tasks,
+// so that the output is the same regardless of the number of workers.
+// A task is not started once a task which precedes it failed (the tasks which follow may already be running),
+// so the tasks preceding the first failed task are always run and its error is always the one which is returned.
+func runTasks(tasks []task, parallel int, run func(task, logger.Logger) ([]Result, error), log logger.Logger) ([]Result, error) {
+	if parallel < 1 {
+		parallel = 1
+	}
+	outcomes := make([]*outcome, len(tasks))
+	for i := range outcomes {
+		outcomes[i] = &outcome{
+			done: make(chan struct{}),
+		}
+	}
+	// the index of the first failed task so far (none if equal to the number of tasks)
+	failed := int64(len(tasks))
+	queue := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				o := outcomes[i]
+				if int64(i) < atomic.LoadInt64(&failed) {
+					o.results, o.err = run(tasks[i], log.WithOutput(&o.out))
+					if o.err != nil {
+						setFailed(&failed, int64(i))
+					}
+				}
+				close(o.done)
+			}
+		}()
+	}
+	go func() {
+		for i := range tasks {
+			queue <- i
+		}
+		close(queue)
+	}()
+	results := []Result{}
+	var err error
+	for _, o := range outcomes {
+		<-o.done
+		if _, e := io.Copy(log.Output(), &o.out); e != nil && err == nil {
+			err = e
+		}
+		results = append(results, o.results...)
+		if o.err != nil && err == nil {
+			err = o.err
+		}
+	}
+	wg.Wait()
+	return results, err
+}
+
+// setFailed sets the index of the first failed task, unless a task with a lower index already failed
+func setFailed(failed *int64, i int64) {
+	for {
+		f := atomic.LoadInt64(failed)
+		if i >= f || atomic.CompareAndSwapInt64(failed, f, i) {
+			return
+		}
+	}
+}
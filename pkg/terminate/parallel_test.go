@@ -0,0 +1,116 @@
+package terminate
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRunTasks(t *testing.T) {
+
+	newTasks := func(names ...string) []task {
+		tasks := make([]task, len(names))
+		for i, n := range names {
+			r := &unstructured.Unstructured{}
+			r.SetName(n)
+			tasks[i] = task{
+				kind:     "pod",
+				resource: r,
+			}
+		}
+		return tasks
+	}
+
+	// run terminates the resources in the reverse order, so that the first tasks are the last to complete
+	run := func(t task, log logger.Logger) ([]Result, error) {
+		time.Sleep(time.Duration(10*(3-len(t.resource.GetName()))) * time.Millisecond)
+		if t.resource.GetName() == "err" {
+			return nil, fmt.Errorf("mock error")
+		}
+		log.Info("%s \"%s\" terminated", t.kind, t.resource.GetName())
+		return []Result{
+			{
+				Kind:   t.kind,
+				Name:   t.resource.GetName(),
+				Status: StatusTerminated,
+			},
+		}, nil
+	}
+
+	t.Run("ok", func(t *testing.T) {
+
+		for _, parallel := range []int{0, 1, 3, 10} {
+			t.Run(fmt.Sprintf("with %d worker(s)", parallel), func(t *testing.T) {
+				// given
+				out := new(bytes.Buffer)
+				// when
+				results, err := runTasks(newTasks("a", "bb", "ccc"), parallel, run, logger.NewLogger(out, 0))
+				// then
+				require.NoError(t, err)
+				require.Len(t, results, 3)
+				assert.Equal(t, "a", results[0].Name)
+				assert.Equal(t, "bb", results[1].Name)
+				assert.Equal(t, "ccc", results[2].Name)
+				assert.Equal(t, "pod \"a\" terminated\npod \"bb\" terminated\npod \"ccc\" terminated\n", out.String())
+			})
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("no task started after failure", func(t *testing.T) {
+			// given
+			out := new(bytes.Buffer)
+			count := int32(0)
+			// when
+			results, err := runTasks(newTasks("a", "err", "bb", "ccc"), 1, func(t task, log logger.Logger) ([]Result, error) {
+				atomic.AddInt32(&count, 1)
+				return run(t, log)
+			}, logger.NewLogger(out, 0))
+			// then
+			require.EqualError(t, err, "mock error")
+			require.Len(t, results, 1)
+			assert.Equal(t, "a", results[0].Name)
+			assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+			assert.Equal(t, "pod \"a\" terminated\n", out.String())
+		})
+
+		t.Run("error of the first failed task", func(t *testing.T) {
+			for _, parallel := range []int{1, 3} {
+				t.Run(fmt.Sprintf("with %d worker(s)", parallel), func(t *testing.T) {
+					// given
+					out := new(bytes.Buffer)
+					started := map[string]bool{}
+					lock := sync.Mutex{}
+					// when
+					results, err := runTasks(newTasks("a", "bb", "err", "ccc"), parallel, func(t task, log logger.Logger) ([]Result, error) {
+						lock.Lock()
+						started[t.resource.GetName()] = true
+						lock.Unlock()
+						if t.resource.GetName() == "bb" {
+							// fails after the next task, which must not prevent this one from running
+							time.Sleep(20 * time.Millisecond)
+							return nil, fmt.Errorf("first error")
+						}
+						return run(t, log)
+					}, logger.NewLogger(out, 0))
+					// then
+					require.EqualError(t, err, "first error")
+					assert.True(t, started["a"])
+					assert.True(t, started["bb"])
+					require.NotEmpty(t, results)
+					assert.Equal(t, "a", results[0].Name)
+				})
+			}
+		})
+	})
+}
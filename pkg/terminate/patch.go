@@ -0,0 +1,108 @@
+package terminate
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+)
+
+// jsonPatchOperation a single operation of a JSON patch (see https://tools.ietf.org/html/rfc6902)
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// newFinalizersPatch returns the JSON patch to remove the finalizers selected by the given filter
+// in the `metadata.finalizers` of the given resource, along with the finalizers to remove.
+// The patch first tests the current `resourceVersion` and finalizers of the resource, so that
+// it is rejected by the server if the resource was modified in the meantime.
+// Returns a `nil` patch if there is no finalizer to remove.
+func newFinalizersPatch(r *unstructured.Unstructured, filter finalizerFilter) ([]byte, []string, error) {
+	finalizers := r.GetFinalizers()
+	removed, _ := filter.split(finalizers)
+	if len(removed) == 0 {
+		return nil, nil, nil
+	}
+	patch := []jsonPatchOperation{
+		{
+			Op:    "test",
+			Path:  "/metadata/resourceVersion",
+			Value: r.GetResourceVersion(),
+		},
+		{
+			Op:    "test",
+			Path:  "/metadata/finalizers",
+			Value: finalizers,
+		},
+	}
+	// remove the entries from the last one, so that the indexes of the other ones remain valid
+	for i := len(finalizers) - 1; i >= 0; i-- {
+		if filter.removes(finalizers[i]) {
+			patch = append(patch, jsonPatchOperation{
+				Op:   "remove",
+				Path: fmt.Sprintf("/metadata/finalizers/%d", i),
+			})
+		}
+	}
+	data, err := json.Marshal(patch)
+	return data, removed, err
+}
+
+// patchBackoff the backoff when retrying to patch a resource which was concurrently modified
+var patchBackoff = retry.DefaultBackoff
+
+// isPatchConflict returns 'true' if the given error occurred because the resource was concurrently modified,
+// i.e, if it is a `409 Conflict` error or a `422 Unprocessable Entity` error, which is what the server
+// returns when a `test` operation of a JSON patch failed
+func isPatchConflict(err error) bool {
+	return errors.IsConflict(err) || errors.IsInvalid(err)
+}
+
+// patchFinalizers removes the finalizers selected by the given filter in the `metadata.finalizers` of the given resource,
+// using a JSON patch. In case of conflict, the resource is fetched again and a new patch is sent (with a bounded backoff).
+// Returns the finalizers that were removed and the resource after it was patched
+func patchFinalizers(cl dynamic.ResourceInterface, resource *unstructured.Unstructured, filter finalizerFilter, opts metav1.PatchOptions, log logger.Logger) ([]string, *unstructured.Unstructured, error) {
+	var removed []string
+	attempt := 0
+	err := retry.OnError(patchBackoff, isPatchConflict, func() error {
+		attempt++
+		if attempt > 1 {
+			log.Debug("reloading '%s/%s' after conflict", resource.GetKind(), resource.GetName())
+			r, err := cl.Get(resource.GetName(), metav1.GetOptions{})
+			if errors.IsNotFound(err) {
+				// the resource is already gone, there is nothing left to patch
+				removed = nil
+				return nil
+			} else if err != nil {
+				return err
+			}
+			resource = r
+		}
+		patch, r, err := newFinalizersPatch(resource, filter)
+		if err != nil || patch == nil {
+			removed = nil
+			return err
+		}
+		log.Debug("patching '%s/%s': %s", resource.GetKind(), resource.GetName(), string(patch))
+		patched, err := cl.Patch(resource.GetName(), types.JSONPatchType, patch, opts)
+		if err != nil {
+			return err
+		}
+		removed = r
+		resource = patched
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return removed, resource, nil
+}
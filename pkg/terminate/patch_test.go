@@ -0,0 +1,120 @@
+package terminate
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestNewFinalizersPatch(t *testing.T) {
+
+	// given
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&corev1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "pasta",
+			Name:            "cookie",
+			ResourceVersion: "42",
+			Finalizers: []string{
+				"foo.example.com/cleanup",
+				"kubernetes.io/pvc-protection",
+				"bar.example.com/cleanup",
+			},
+		},
+	})
+	require.NoError(t, err)
+	resource := &unstructured.Unstructured{
+		Object: object,
+	}
+
+	t.Run("all finalizers", func(t *testing.T) {
+		// when
+		patch, removed, err := newFinalizersPatch(resource, finalizerFilter{})
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"foo.example.com/cleanup", "kubernetes.io/pvc-protection", "bar.example.com/cleanup"}, removed)
+		assert.JSONEq(t, `[
+			{"op":"test","path":"/metadata/resourceVersion","value":"42"},
+			{"op":"test","path":"/metadata/finalizers","value":["foo.example.com/cleanup","kubernetes.io/pvc-protection","bar.example.com/cleanup"]},
+			{"op":"remove","path":"/metadata/finalizers/2"},
+			{"op":"remove","path":"/metadata/finalizers/1"},
+			{"op":"remove","path":"/metadata/finalizers/0"}
+		]`, string(patch))
+	})
+
+	t.Run("selected finalizers", func(t *testing.T) {
+		// when
+		patch, removed, err := newFinalizersPatch(resource, finalizerFilter{remove: []string{"*.example.com/*"}})
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"foo.example.com/cleanup", "bar.example.com/cleanup"}, removed)
+		assert.JSONEq(t, `[
+			{"op":"test","path":"/metadata/resourceVersion","value":"42"},
+			{"op":"test","path":"/metadata/finalizers","value":["foo.example.com/cleanup","kubernetes.io/pvc-protection","bar.example.com/cleanup"]},
+			{"op":"remove","path":"/metadata/finalizers/2"},
+			{"op":"remove","path":"/metadata/finalizers/0"}
+		]`, string(patch))
+	})
+
+	t.Run("no matching finalizer", func(t *testing.T) {
+		// when
+		patch, removed, err := newFinalizersPatch(resource, finalizerFilter{remove: []string{"unknown"}})
+		// then
+		require.NoError(t, err)
+		assert.Nil(t, patch)
+		assert.Empty(t, removed)
+	})
+}
+
+func TestPatchFinalizers(t *testing.T) {
+
+	// given
+	log := logger.NewLogger(os.Stdout, 1) // includes 'debug' messages
+	kubeconfig, server := setup(t)
+	defer server.Close()
+	cl, err := newResourceClient(kubeconfig, "default", metav1.APIResource{
+		Version:    "v1",
+		Kind:       "Pod",
+		Name:       "pods",
+		Namespaced: true,
+	})
+	require.NoError(t, err)
+
+	t.Run("ok", func(t *testing.T) {
+
+		t.Run("without conflict", func(t *testing.T) {
+			// given
+			resource, err := cl.Get("cookie", metav1.GetOptions{})
+			require.NoError(t, err)
+			// when
+			removed, patched, err := patchFinalizers(cl, resource, finalizerFilter{}, metav1.PatchOptions{}, log)
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, []string{"cheesecake"}, removed)
+			assert.Empty(t, patched.GetFinalizers())
+		})
+
+		t.Run("after conflict", func(t *testing.T) {
+			// given
+			resource, err := cl.Get("muffin", metav1.GetOptions{})
+			require.NoError(t, err)
+			// when
+			removed, patched, err := patchFinalizers(cl, resource, finalizerFilter{keep: []string{"blueberry"}}, metav1.PatchOptions{}, log)
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, []string{"cheesecake"}, removed)
+			assert.Equal(t, []string{"blueberry"}, patched.GetFinalizers())
+			assert.Equal(t, "2", patched.GetResourceVersion()) // patch applied on the reloaded resource
+		})
+	})
+}
@@ -0,0 +1,92 @@
+package terminate
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Protection the guardrails against the termination of sensitive resources.
+// Patterns may contain `*` (any sequence of characters) and `?` (any single character) wildcards, eg: `openshift-*`
+type Protection struct {
+	// Contexts the patterns of the kubeconfig contexts in which no resource can be terminated (eg: `prod-*`)
+	Contexts []string
+	// Namespaces the patterns of the namespaces which cannot be terminated, along with the resources they contain (eg: `kube-system`)
+	Namespaces []string
+	// Finalizers the patterns of the finalizers which must never be removed (eg: `kubernetes.io/pv-protection`)
+	Finalizers []string
+}
+
+// CheckContext returns a `ProtectedError` if the given kubeconfig context is protected
+func (p Protection) CheckContext(context string) error {
+	if matchAny(p.Contexts, context) {
+		return ProtectedError{msg: fmt.Sprintf("refusing to terminate resources in protected context '%s'", context)}
+	}
+	return nil
+}
+
+// check returns a `ProtectedError` if the given resource is a protected namespace, or if it is in a protected namespace,
+// or if any of the finalizers which would be removed by the given filter is protected
+func (p Protection) check(apiresource metav1.APIResource, kind string, resource *unstructured.Unstructured, filter finalizerFilter) error {
+	name := resource.GetName()
+	if isNamespace(apiresource) && matchAny(p.Namespaces, name) {
+		return ProtectedError{msg: fmt.Sprintf("refusing to terminate protected namespace '%s'", name)}
+	}
+	if ns := resource.GetNamespace(); ns != "" && matchAny(p.Namespaces, ns) {
+		return ProtectedError{msg: fmt.Sprintf("refusing to terminate %s \"%s\" in protected namespace '%s'", kind, name, ns)}
+	}
+	finalizers := resource.GetFinalizers()
+	if isNamespace(apiresource) {
+		specFinalizers, _, _ := unstructured.NestedStringSlice(resource.Object, "spec", "finalizers")
+		finalizers = append(finalizers, specFinalizers...)
+	}
+	removed, _ := filter.split(finalizers)
+	protected := []string{}
+	for _, f := range removed {
+		if matchAny(p.Finalizers, f) {
+			protected = append(protected, f)
+		}
+	}
+	if len(protected) > 0 {
+		return ProtectedError{msg: fmt.Sprintf("refusing to remove protected finalizer(s) %v on %s \"%s\"", protected, kind, name)}
+	}
+	return nil
+}
+
+// checkProtection checks that none of the resources of the given tasks is protected. Unless `opts.ContinueOnError` is set,
+// the error of the first protected resource is returned. Otherwise, the error is kept in the task and reported with its result.
+// Resources which would be skipped anyway (ie, which are not being deleted) are not checked.
+func checkProtection(tasks []task, opts Options) error {
+	filter := newFinalizerFilter(opts)
+	for i, t := range tasks {
+		if err := checkProtection(t.content, opts); err != nil {
+			return err
+		}
+		if t.err != nil || (checkDeletionTimestamp(t.resource) != nil && !opts.ForceLive) {
+			continue
+		}
+		if err := opts.Protection.check(t.apiresource, t.kind, t.resource, filter); err != nil && !opts.ContinueOnError {
+			return err
+		} else if err != nil {
+			tasks[i].err = err
+		}
+	}
+	return nil
+}
+
+// ProtectedError the error to return when a resource (or all the resources in a context) cannot be terminated
+// because it is protected
+type ProtectedError struct {
+	msg string
+}
+
+func (e ProtectedError) Error() string {
+	return e.msg
+}
+
+// IsProtectedError returns 'true' if the given error is a ProtectedError
+func IsProtectedError(err error) bool {
+	_, is := err.(ProtectedError)
+	return is
+}
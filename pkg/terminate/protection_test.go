@@ -0,0 +1,141 @@
+package terminate
+
+import (
+	"os"
+	"testing"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestProtection(t *testing.T) {
+
+	// given
+	p := Protection{
+		Contexts:   []string{"prod-*"},
+		Namespaces: []string{"kube-system", "openshift-*"},
+		Finalizers: []string{"kubernetes.io/pv-protection"},
+	}
+	pods := metav1.APIResource{Version: "v1", Name: "pods", Kind: "Pod", Namespaced: true}
+	namespaces := metav1.APIResource{Version: "v1", Name: "namespaces", Kind: "Namespace"}
+	newResource := func(namespace, name string, finalizers ...string) *unstructured.Unstructured {
+		r := &unstructured.Unstructured{}
+		r.SetNamespace(namespace)
+		r.SetName(name)
+		r.SetFinalizers(finalizers)
+		return r
+	}
+
+	t.Run("contexts", func(t *testing.T) {
+		assert.NoError(t, p.CheckContext("dev"))
+		assert.NoError(t, Protection{}.CheckContext("prod-eu"))
+		err := p.CheckContext("prod-eu")
+		require.EqualError(t, err, "refusing to terminate resources in protected context 'prod-eu'")
+		assert.True(t, IsProtectedError(err))
+	})
+
+	t.Run("unprotected resource", func(t *testing.T) {
+		err := p.check(pods, "pod", newResource("default", "cookie", "cheesecake"), finalizerFilter{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("protected namespace", func(t *testing.T) {
+		err := p.check(namespaces, "ns", newResource("", "openshift-monitoring"), finalizerFilter{})
+		require.EqualError(t, err, "refusing to terminate protected namespace 'openshift-monitoring'")
+		assert.True(t, IsProtectedError(err))
+	})
+
+	t.Run("resource in protected namespace", func(t *testing.T) {
+		err := p.check(pods, "pod", newResource("kube-system", "coredns"), finalizerFilter{})
+		require.EqualError(t, err, `refusing to terminate pod "coredns" in protected namespace 'kube-system'`)
+	})
+
+	t.Run("protected finalizer", func(t *testing.T) {
+		err := p.check(pods, "pod", newResource("default", "cookie", "cheesecake", "kubernetes.io/pv-protection"), finalizerFilter{})
+		require.EqualError(t, err, `refusing to remove protected finalizer(s) [kubernetes.io/pv-protection] on pod "cookie"`)
+	})
+
+	t.Run("protected finalizer kept", func(t *testing.T) {
+		filter := finalizerFilter{keep: []string{"kubernetes.io/*"}}
+		err := p.check(pods, "pod", newResource("default", "cookie", "cheesecake", "kubernetes.io/pv-protection"), filter)
+		assert.NoError(t, err)
+	})
+
+	t.Run("protected spec finalizer of namespace", func(t *testing.T) {
+		ns := newResource("", "pasta")
+		err := unstructured.SetNestedStringSlice(ns.Object, []string{"kubernetes"}, "spec", "finalizers")
+		require.NoError(t, err)
+		err = Protection{Finalizers: []string{"kubernetes"}}.check(namespaces, "namespace", ns, finalizerFilter{})
+		require.EqualError(t, err, `refusing to remove protected finalizer(s) [kubernetes] on namespace "pasta"`)
+	})
+}
+
+func TestTerminateWithProtection(t *testing.T) {
+
+	// given
+	log := logger.NewLogger(os.Stdout, 0)
+	metadata := []ResourceMetadata{
+		{
+			Kind: "pod",
+			Name: "cookie",
+		},
+		{
+			Kind: "namespace",
+			Name: "pasta",
+		},
+	}
+	opts := Options{
+		Protection: Protection{
+			Namespaces: []string{"pasta"},
+		},
+	}
+
+	t.Run("stop at protected resource", func(t *testing.T) {
+		// given
+		kubeconfig, server := setup(t)
+		defer server.Close()
+		// when
+		results, err := Terminate(metadata, kubeconfig, opts, log)
+		// then
+		require.EqualError(t, err, "refusing to terminate protected namespace 'pasta'")
+		assert.Empty(t, results) // the cookie pod was not terminated either
+	})
+
+	t.Run("continue on error", func(t *testing.T) {
+		// given
+		kubeconfig, server := setup(t)
+		defer server.Close()
+		opts := opts
+		opts.ContinueOnError = true
+		// when
+		results, err := Terminate(metadata, kubeconfig, opts, log)
+		// then
+		require.Error(t, err)
+		require.True(t, IsTerminationFailedError(err))
+		require.Len(t, results, 2)
+		assert.Equal(t, StatusTerminated, results[0].Status)
+		assert.Equal(t, StatusFailed, results[1].Status)
+		assert.True(t, IsProtectedError(results[1].Error))
+	})
+
+	t.Run("protected finalizer in namespace content", func(t *testing.T) {
+		// given
+		kubeconfig, server := setup(t)
+		defer server.Close()
+		opts := Options{
+			Content: true,
+			Protection: Protection{
+				Finalizers: []string{"cheese*"},
+			},
+		}
+		// when
+		results, err := Terminate(metadata[1:], kubeconfig, opts, log)
+		// then
+		require.EqualError(t, err, `refusing to remove protected finalizer(s) [cheesecake] on pod "penne"`)
+		assert.Empty(t, results)
+	})
+}
@@ -0,0 +1,88 @@
+package terminate
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// apiResourceResolver looks up the API resources matching resource types, and keeps them in cache
+// if we have multiple resources of the same type to terminate. It is safe for concurrent use.
+type apiResourceResolver struct {
+	cl    discovery.DiscoveryInterface
+	mu    sync.Mutex
+	cache map[string]metav1.APIResource
+}
+
+func newAPIResourceResolver(cl discovery.DiscoveryInterface) *apiResourceResolver {
+	return &apiResourceResolver{
+		cl:    cl,
+		cache: map[string]metav1.APIResource{},
+	}
+}
+
+// resolve returns the API resource matching the given resource type
+func (r *apiResourceResolver) resolve(n string, log logger.Logger) (metav1.APIResource, error) {
+	// the lock is held during the lookup, so that concurrent lookups of the same type only trigger a single discovery
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if apiresource, exists := r.cache[n]; exists {
+		return apiresource, nil
+	}
+	apiresource, err := lookupAPIResource(n, r.cl, log)
+	if err != nil {
+		return metav1.APIResource{}, err
+	}
+	r.cache[n] = apiresource
+	return apiresource, nil
+}
+
+// resolveKind returns the API resource with the given kind in the given group/version (eg: from a manifest)
+func (r *apiResourceResolver) resolveKind(apiVersion, kind string) (metav1.APIResource, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := apiVersion + "/" + kind
+	if apiresource, exists := r.cache[key]; exists {
+		return apiresource, nil
+	}
+	apiresource, err := findAPIResource(r.cl, apiVersion, kind)
+	if err != nil {
+		return metav1.APIResource{}, err
+	}
+	r.cache[key] = apiresource
+	return apiresource, nil
+}
+
+// resolveMetadata returns the API resource of the given resource metadata, using its API version if it is known
+func (r *apiResourceResolver) resolveMetadata(m ResourceMetadata, log logger.Logger) (metav1.APIResource, error) {
+	if m.APIVersion != "" {
+		return r.resolveKind(m.APIVersion, m.Kind)
+	}
+	return r.resolve(m.Kind, log)
+}
+
+// findAPIResource returns the API resource with the given kind in the given group/version
+func findAPIResource(cl discovery.DiscoveryInterface, apiVersion, kind string) (metav1.APIResource, error) {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return metav1.APIResource{}, err
+	}
+	rl, err := cl.ServerResourcesForGroupVersion(apiVersion)
+	if err != nil {
+		return metav1.APIResource{}, err
+	}
+	for _, r := range rl.APIResources {
+		if r.Kind == kind && !strings.Contains(r.Name, "/") {
+			r.Group = gv.Group
+			r.Version = gv.Version
+			return r, nil
+		}
+	}
+	return metav1.APIResource{}, fmt.Errorf("unknown resource kind: '%s' in '%s'", kind, apiVersion)
+}
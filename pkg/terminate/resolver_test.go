@@ -0,0 +1,84 @@
+package terminate
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveAPIResource(t *testing.T) {
+
+	// given
+	log := logger.NewLogger(os.Stdout, 0)
+	kubeconfig, server := setup(t)
+	defer server.Close()
+	client, err := newDiscoveryClient(kubeconfig)
+	require.NoError(t, err)
+
+	t.Run("concurrent lookups", func(t *testing.T) {
+		// given
+		resolver := newAPIResourceResolver(client)
+		wg := sync.WaitGroup{}
+		types := []string{"pod", "pods", "ns", "ct", "deploy", "pod", "ns", "ct"}
+		kinds := make([]string, len(types))
+		// when
+		for i, n := range types {
+			wg.Add(1)
+			go func(i int, n string) {
+				defer wg.Done()
+				r, err := resolver.resolve(n, log)
+				assert.NoError(t, err)
+				kinds[i] = r.Kind
+			}(i, n)
+		}
+		wg.Wait()
+		// then
+		assert.Equal(t, []string{"Pod", "Pod", "Namespace", "CustomType", "Deployment", "Pod", "Namespace", "CustomType"}, kinds)
+		assert.Len(t, resolver.cache, 5)
+	})
+
+	t.Run("unknown resource type", func(t *testing.T) {
+		// given
+		resolver := newAPIResourceResolver(client)
+		// when
+		_, err := resolver.resolve("unknown", log)
+		// then
+		require.EqualError(t, err, "unknown resource type: 'unknown'")
+		assert.Empty(t, resolver.cache)
+	})
+
+	t.Run("kind and API version", func(t *testing.T) {
+		// given
+		resolver := newAPIResourceResolver(client)
+		// when
+		r, err := resolver.resolveMetadata(ResourceMetadata{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Name:       "latte",
+		}, log)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "deployments", r.Name)
+		assert.Equal(t, "apps", r.Group)
+		assert.Equal(t, "v1", r.Version)
+	})
+
+	t.Run("unknown kind in API version", func(t *testing.T) {
+		// given
+		resolver := newAPIResourceResolver(client)
+		// when
+		_, err := resolver.resolveMetadata(ResourceMetadata{
+			APIVersion: "apps/v1",
+			Kind:       "StatefulSet",
+			Name:       "latte",
+		}, log)
+		// then
+		require.EqualError(t, err, "unknown resource kind: 'StatefulSet' in 'apps/v1'")
+		assert.Empty(t, resolver.cache)
+	})
+}
@@ -0,0 +1,167 @@
+package terminate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+// StatusRestored the resource was re-created from its backup
+const StatusRestored Status = "restored"
+
+// Restore re-creates the resources saved in the given backup directory (or file), with their server-managed fields stripped.
+// Only the resources matching the given namespace (if not empty) and one of the given filters (if any) are restored.
+// Resources which already exist are skipped.
+func Restore(path string, namespace string, filters []ResourceMetadata, kubeconfig clientcmd.ClientConfig, log logger.Logger) ([]Result, error) {
+	discoveryClient, err := newDiscoveryClient(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	resolver := newAPIResourceResolver(discoveryClient)
+	// resolve the types of the filters first, to match them with the kinds of the backups
+	filterTypes := make([]metav1.APIResource, len(filters))
+	for i, f := range filters {
+		if filterTypes[i], err = resolver.resolve(f.Kind, log); err != nil {
+			return nil, err
+		}
+	}
+	backups, err := loadBackups(path)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := newDynamicClient(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	results := []Result{}
+	for _, bk := range backups {
+		b := bk.resource
+		if (namespace != "" && b.GetNamespace() != namespace) || !matchFilters(b, filters, filterTypes) {
+			continue
+		}
+		log.Debug("restoring '%s/%s' from %s", b.GetKind(), b.GetName(), bk.path)
+		apiresource, err := resolver.resolveKind(b.GetAPIVersion(), b.GetKind())
+		if err != nil {
+			return results, err
+		}
+		kind := strings.ToLower(b.GetKind())
+		result := Result{
+			APIResource: apiresource,
+			Kind:        kind,
+			Namespace:   b.GetNamespace(),
+			Name:        b.GetName(),
+		}
+		cl := dynamicClient.Resource(schema.GroupVersionResource{
+			Group:    apiresource.Group,
+			Version:  apiresource.Version,
+			Resource: apiresource.Name,
+		})
+		var rc dynamic.ResourceInterface = cl
+		if apiresource.Namespaced {
+			rc = cl.Namespace(b.GetNamespace())
+		}
+		if _, err := rc.Create(stripServerFields(b), metav1.CreateOptions{}); errors.IsAlreadyExists(err) {
+			log.Info("%s \"%s\" skipped (already exists)", kind, b.GetName())
+			result.Status = StatusSkipped
+			result.Reason = err.Error()
+			results = append(results, result)
+			continue
+		} else if err != nil {
+			return results, err
+		}
+		log.Info("%s \"%s\" restored", kind, b.GetName())
+		result.Status = StatusRestored
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		log.Info("No resources found")
+	}
+	return results, nil
+}
+
+// backup a resource saved in a backup file
+type backup struct {
+	path     string
+	resource *unstructured.Unstructured
+}
+
+// loadBackups returns the resources saved in the given backup file, or in all the `.yaml` files
+// of the given backup directory (and its subdirectories), sorted by path
+func loadBackups(path string) ([]backup, error) {
+	paths := []string{}
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && filepath.Ext(p) == ".yaml" {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	backups := make([]backup, 0, len(paths))
+	for _, p := range paths {
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		b := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(data, &b.Object); err != nil {
+			return nil, fmt.Errorf("invalid backup file '%s': %w", p, err)
+		}
+		backups = append(backups, backup{
+			path:     p,
+			resource: b,
+		})
+	}
+	return backups, nil
+}
+
+// matchFilters returns 'true' if there is no filter, or if the given resource matches the type
+// and name (and namespace, if specified) of one of the given filters
+func matchFilters(r *unstructured.Unstructured, filters []ResourceMetadata, filterTypes []metav1.APIResource) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	gv, err := schema.ParseGroupVersion(r.GetAPIVersion())
+	if err != nil {
+		return false
+	}
+	for i, f := range filters {
+		if filterTypes[i].Group == gv.Group && filterTypes[i].Kind == r.GetKind() &&
+			f.Name == r.GetName() &&
+			(f.Namespace == "" || f.Namespace == r.GetNamespace()) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripServerFields returns a copy of the given resource without the fields which are managed by the server
+// (or which prevent its re-creation), so that it can be re-created
+func stripServerFields(r *unstructured.Unstructured) *unstructured.Unstructured {
+	r = r.DeepCopy()
+	for _, f := range []string{"resourceVersion", "uid", "selfLink", "creationTimestamp", "deletionTimestamp", "deletionGracePeriodSeconds", "generation", "managedFields"} {
+		unstructured.RemoveNestedField(r.Object, "metadata", f)
+	}
+	// the owners have most likely been deleted, in which case the garbage collector would delete the re-created resource
+	unstructured.RemoveNestedField(r.Object, "metadata", "ownerReferences")
+	unstructured.RemoveNestedField(r.Object, "status")
+	return r
+}
@@ -0,0 +1,165 @@
+package terminate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRestore(t *testing.T) {
+
+	// given
+	kubeconfig, server := setup(t)
+	defer server.Close()
+	dir := newBackupDir(t)
+	defer os.RemoveAll(dir)
+
+	t.Run("all resources", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		// when
+		results, err := Restore(dir, "", nil, kubeconfig, logger.NewLogger(out, 0))
+		// then
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		assert.Equal(t, "pod \"cookie\" skipped (already exists)\npod \"scone\" restored\npod \"scone\" restored\n", out.String())
+		assert.Equal(t, StatusSkipped, results[0].Status)
+		assert.Equal(t, StatusRestored, results[1].Status)
+		assert.Equal(t, "default", results[1].Namespace)
+		assert.Equal(t, StatusRestored, results[2].Status)
+		assert.Equal(t, "dessert", results[2].Namespace)
+	})
+
+	t.Run("resources in namespace", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		// when
+		results, err := Restore(dir, "dessert", nil, kubeconfig, logger.NewLogger(out, 0))
+		// then
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "scone", results[0].Name)
+		assert.Equal(t, "dessert", results[0].Namespace)
+	})
+
+	t.Run("selected resources", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		// when
+		results, err := Restore(dir, "", []ResourceMetadata{
+			{
+				Kind:      "po",
+				Name:      "scone",
+				Namespace: "default",
+			},
+		}, kubeconfig, logger.NewLogger(out, 0))
+		// then
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "scone", results[0].Name)
+		assert.Equal(t, "default", results[0].Namespace)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		// when
+		results, err := Restore(dir, "", []ResourceMetadata{
+			{
+				Kind: "deploy",
+				Name: "scone",
+			},
+		}, kubeconfig, logger.NewLogger(out, 0))
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, results)
+		assert.Equal(t, "No resources found\n", out.String())
+	})
+
+	t.Run("unknown backup dir", func(t *testing.T) {
+		// when
+		_, err := Restore("/does/not/exist", "", nil, kubeconfig, logger.NewLogger(new(bytes.Buffer), 0))
+		// then
+		require.Error(t, err)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestStripServerFields(t *testing.T) {
+	// given
+	r := newBackupPod("default", "scone")
+	// when
+	actual := stripServerFields(r)
+	// then
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"namespace":  "default",
+			"name":       "scone",
+			"finalizers": []interface{}{"cheesecake"},
+		},
+		"spec": map[string]interface{}{
+			"nodeName": "node-1",
+		},
+	}, actual.Object)
+	// original resource is not modified
+	assert.Equal(t, "1", r.GetResourceVersion())
+}
+
+// newBackupDir returns a backup directory with the `cookie` and `scone` pods in the `default` namespace,
+// and the `scone` pod in the `dessert` namespace
+func newBackupDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "backups")
+	require.NoError(t, err)
+	pods := metav1.APIResource{Version: "v1", Name: "pods"}
+	for _, r := range []*unstructured.Unstructured{
+		newBackupPod("default", "cookie"),
+		newBackupPod("default", "scone"),
+		newBackupPod("dessert", "scone"),
+	} {
+		_, err := backupResource(dir, pods, r)
+		require.NoError(t, err)
+	}
+	return dir
+}
+
+func newBackupPod(namespace, name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata": map[string]interface{}{
+				"namespace":         namespace,
+				"name":              name,
+				"uid":               name + "-uid",
+				"resourceVersion":   "1",
+				"creationTimestamp": "2020-02-01T12:00:00Z",
+				"deletionTimestamp": "2020-03-01T12:00:00Z",
+				"finalizers":        []interface{}{"cheesecake"},
+				"ownerReferences": []interface{}{
+					map[string]interface{}{
+						"apiVersion": "apps/v1",
+						"kind":       "ReplicaSet",
+						"name":       "bakery",
+						"uid":        "bakery-uid",
+					},
+				},
+			},
+			"spec": map[string]interface{}{
+				"nodeName": "node-1",
+			},
+			"status": map[string]interface{}{
+				"phase": "Terminating",
+			},
+		},
+	}
+}
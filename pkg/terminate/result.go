@@ -0,0 +1,109 @@
+package terminate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Status the status of a resource after its termination was attempted
+type Status string
+
+const (
+	// StatusTerminated the finalizers were removed and the resource was deleted
+	StatusTerminated Status = "terminated"
+	// StatusSkipped the resource was left untouched (eg: because it is not being deleted)
+	StatusSkipped Status = "skipped"
+	// StatusNotFound the resource does not exist (anymore)
+	StatusNotFound Status = "not-found"
+	// StatusFailed the termination of the resource failed
+	StatusFailed Status = "failed"
+	// StatusReblocked the resource was deleted, but it was blocked again by some finalizers (eg: re-added by a controller)
+	StatusReblocked Status = "re-blocked"
+)
+
+// Result the result of the termination of a single resource
+type Result struct {
+	// APIResource the type of the resource (unknown if the type could not be resolved)
+	APIResource metav1.APIResource
+	Kind        string
+	Namespace   string
+	Name        string
+	Status      Status
+	// Cleared the finalizer fields that were cleared (eg: `metadata.finalizers`, `spec.finalizers`)
+	Cleared []string
+	// Finalizers the finalizers that were removed
+	Finalizers []string
+	// DeleteIssued 'true' if a DELETE request was sent for the resource
+	DeleteIssued bool
+	// Backup the path to the backup of the resource before it was modified (if applicable)
+	Backup string
+	// Reason the reason why the resource was skipped (if applicable)
+	Reason string
+	// Error the error which occurred during the termination of the resource (if applicable)
+	Error error
+	// DryRun the dry-run strategy of the termination
+	DryRun DryRunStrategy
+}
+
+// failedResult returns the given result of a resource whose termination failed with the given error,
+// and logs the error. A `NotFound` error is not considered as a failure, and a `ReblockedError` is reported as such.
+// The given result keeps track of what was already done before the error (eg: the finalizers which were removed)
+func failedResult(result Result, err error, log logger.Logger) Result {
+	if errors.IsNotFound(err) {
+		log.Info("%s \"%s\" not found", result.Kind, result.Name)
+		result.Status = StatusNotFound
+		result.Reason = err.Error()
+		return result
+	}
+	if e, ok := err.(ReblockedError); ok {
+		log.Info("%s \"%s\" re-blocked by finalizer(s) %v", result.Kind, result.Name, e.Finalizers())
+		result.Status = StatusReblocked
+		result.Error = err
+		return result
+	}
+	log.Info("%s \"%s\" failed: %v", result.Kind, result.Name, err)
+	result.Status = StatusFailed
+	result.Error = err
+	return result
+}
+
+// failedResults returns the results of the resources whose termination failed (including the re-blocked ones)
+func failedResults(results []Result) []Result {
+	failures := []Result{}
+	for _, r := range results {
+		if r.Status == StatusFailed || r.Status == StatusReblocked {
+			failures = append(failures, r)
+		}
+	}
+	return failures
+}
+
+// TerminationFailedError the error returned when the termination of some resources failed
+// while the other ones were still attempted
+type TerminationFailedError struct {
+	failures []Result
+}
+
+func (e TerminationFailedError) Error() string {
+	msgs := make([]string, 0, len(e.failures))
+	for _, f := range e.failures {
+		msgs = append(msgs, fmt.Sprintf("%s \"%s\": %v", f.Kind, f.Name, f.Error))
+	}
+	return fmt.Sprintf("failed to terminate %d resource(s): %s", len(e.failures), strings.Join(msgs, "; "))
+}
+
+// Failures returns the results of the resources whose termination failed
+func (e TerminationFailedError) Failures() []Result {
+	return e.failures
+}
+
+// IsTerminationFailedError returns 'true' if the given error is a TerminationFailedError
+func IsTerminationFailedError(err error) bool {
+	_, is := err.(TerminationFailedError)
+	return is
+}
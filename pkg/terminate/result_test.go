@@ -0,0 +1,80 @@
+package terminate
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestFailedResult(t *testing.T) {
+
+	t.Run("not found", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		err := errors.NewNotFound(schema.GroupResource{Resource: "pods"}, "cookie")
+		// when
+		r := failedResult(Result{Kind: "pod", Namespace: "default", Name: "cookie"}, err, logger.NewLogger(out, 0))
+		// then
+		assert.Equal(t, StatusNotFound, r.Status)
+		assert.Equal(t, `pods "cookie" not found`, r.Reason)
+		assert.NoError(t, r.Error)
+		assert.Equal(t, "pod \"cookie\" not found\n", out.String())
+	})
+
+	t.Run("re-blocked", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		err := ReblockedError{name: "cookie", finalizers: []string{"cheesecake"}}
+		// when
+		r := failedResult(Result{
+			APIResource:  metav1.APIResource{Name: "pods", Version: "v1"},
+			Kind:         "pod",
+			Namespace:    "default",
+			Name:         "cookie",
+			Finalizers:   []string{"cheesecake"},
+			DeleteIssued: true,
+		}, err, logger.NewLogger(out, 0))
+		// then
+		assert.Equal(t, StatusReblocked, r.Status)
+		assert.Equal(t, err, r.Error)
+		// what was done before the error is kept
+		assert.Equal(t, []string{"cheesecake"}, r.Finalizers)
+		assert.True(t, r.DeleteIssued)
+		assert.Equal(t, "pod \"cookie\" re-blocked by finalizer(s) [cheesecake]\n", out.String())
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		// given
+		out := new(bytes.Buffer)
+		err := fmt.Errorf("mock error")
+		// when
+		r := failedResult(Result{Kind: "pod", Namespace: "default", Name: "cookie"}, err, logger.NewLogger(out, 0))
+		// then
+		assert.Equal(t, StatusFailed, r.Status)
+		assert.Equal(t, err, r.Error)
+		assert.Equal(t, "pod \"cookie\" failed: mock error\n", out.String())
+	})
+}
+
+func TestTerminationFailedError(t *testing.T) {
+	// given
+	results := []Result{
+		{Kind: "pod", Name: "cookie", Status: StatusTerminated},
+		{Kind: "pod", Name: "crumble", Status: StatusFailed, Error: fmt.Errorf("mock error")},
+		{Kind: "pod", Name: "unknown", Status: StatusNotFound},
+		{Kind: "pod", Name: "muffin", Status: StatusFailed, Error: fmt.Errorf("another error")},
+	}
+	// when
+	err := TerminationFailedError{failures: failedResults(results)}
+	// then
+	assert.True(t, IsTerminationFailedError(err))
+	assert.Equal(t, `failed to terminate 2 resource(s): pod "crumble": mock error; pod "muffin": another error`, err.Error())
+	assert.Len(t, err.Failures(), 2)
+}
@@ -2,9 +2,9 @@ package terminate
 
 import (
 	"fmt"
-	"io"
-	"io/ioutil"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/xcoulon/kubectl-terminate/pkg/logger"
@@ -18,68 +18,350 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 )
 
-// ResourceMetadata the metadata of the resource to delete
+// ResourceMetadata the metadata of the resource(s) to delete.
+// Resources are either matched by their name, or by label and/or field selectors
 type ResourceMetadata struct {
-	Kind      string
-	Namespace string
-	Name      string
+	// APIVersion the group/version of the resource type, if known (eg: from a manifest), in which case
+	// `Kind` is the exact kind of the resource (eg: `Deployment`)
+	APIVersion    string
+	Kind          string
+	Namespace     string
+	Name          string
+	LabelSelector string
+	FieldSelector string
 }
 
-// Terminate terminates the resource with the given type and name, ie, it removes
-// all pending finalizers and deletes it afterwards
-func Terminate(metadata []ResourceMetadata, kubeconfigReader io.Reader, log logger.Logger) error {
-	kubeconfig, err := newKubeConfig(kubeconfigReader)
-	if err != nil {
-		return err
+// displayKind returns the kind of the resources in the messages, ie, the type given by the user,
+// or the lowercase kind if it comes from a manifest (eg: `deployment`)
+func (m ResourceMetadata) displayKind() string {
+	if m.APIVersion != "" {
+		return strings.ToLower(m.Kind)
 	}
+	return m.Kind
+}
+
+// Options the options to terminate resources
+type Options struct {
+	// ForceLive terminates the resources even if they are not being deleted,
+	// ie, if they have no `metadata.deletionTimestamp`
+	ForceLive bool
+	// DryRun the dry-run strategy (no dry-run if empty)
+	DryRun DryRunStrategy
+	// Finalizers the patterns of the finalizers to remove (all finalizers if empty)
+	Finalizers []string
+	// KeepFinalizers the patterns of the finalizers to keep
+	KeepFinalizers []string
+	// Content also terminates the remaining resources which are being deleted in the namespaces to terminate
+	// (other resource types are not affected). These resources are loaded, checked and confirmed along with their namespace.
+	Content bool
+	// PropagationPolicy the deletion propagation policy for the dependents of the resources (server default if empty)
+	PropagationPolicy metav1.DeletionPropagation
+	// GracePeriodSeconds the grace period of the deletion (resource default if nil), eg: `0` to force the deletion
+	// of pods on an unreachable node
+	GracePeriodSeconds *int64
+	// Parallel the maximum number of resources to terminate concurrently (one at a time if not set)
+	Parallel int
+	// ContinueOnError attempts to terminate all resources, even if the termination of some of them failed
+	ContinueOnError bool
+	// BackupDir the directory in which the resources are saved before they are modified (no backup if empty)
+	BackupDir string
+	// Wait waits until the resources are actually removed, or until the `Timeout` expires
+	Wait bool
+	// Timeout the maximum duration to wait for the removal of each resource (no limit if not set)
+	Timeout time.Duration
+	// AuditLog the log in which each resource is recorded once it was modified (no audit if nil)
+	AuditLog *AuditLog
+	// EventRecorder records an Event on each resource whose finalizers were removed, and on its namespace (no event if nil)
+	EventRecorder *EventRecorder
+	// Protection the resources which cannot be terminated (no protection if empty)
+	Protection Protection
+	// Confirm asks for the confirmation of the termination of the resources, once they are all loaded
+	// and before any of them is modified (all resources are terminated if nil)
+	Confirm ConfirmFunc
+}
+
+// Terminate terminates the resource with the given type and name (or all the resources
+// matching the given selectors), ie, it removes all pending finalizers and deletes it afterwards.
+// Unless `opts.ForceLive` is set, resources which are not being deleted are skipped.
+// All resources are loaded first, then terminated (concurrently if `opts.Parallel` is greater than 1),
+// and the results are returned in the order in which the resources were loaded.
+// Resources protected by `opts.Protection` are refused before any resource is modified.
+// If `opts.Confirm` is set, the resources which were not confirmed are skipped.
+// Unless `opts.ContinueOnError` is set, the termination stops at the first error. Otherwise, all resources
+// are attempted, and a `TerminationFailedError` is returned if the termination of any of them failed.
+func Terminate(metadata []ResourceMetadata, kubeconfig clientcmd.ClientConfig, opts Options, log logger.Logger) ([]Result, error) {
 	discoveryClient, err := newDiscoveryClient(kubeconfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	resolver := newAPIResourceResolver(discoveryClient)
+	tasks := []task{}
 	for _, m := range metadata {
-		log.Debug("loading API resource")
-		apiresource, err := lookupAPIResource(m.Kind, discoveryClient, log)
-		if err != nil {
-			return err
+		t, err := loadTasks(kubeconfig, resolver, m, opts, log)
+		if err != nil && !opts.ContinueOnError {
+			return []Result{}, err
+		} else if err != nil {
+			// keep track of the error, which will be reported in order with the other results
+			t = []task{
+				{
+					kind:      m.displayKind(),
+					namespace: m.Namespace,
+					name:      m.Name,
+					err:       err,
+				},
+			}
 		}
-		log.Debug("initializing client")
-		cl, err := newResourceClient(kubeconfig, m.Namespace, apiresource)
+		tasks = append(tasks, t...)
+	}
+	if opts.Content {
+		// load the content of the namespaces first, so that it is also checked and confirmed before any resource is modified
+		for i, t := range tasks {
+			if t.err != nil || !isNamespace(t.apiresource) || (checkDeletionTimestamp(t.resource) != nil && !opts.ForceLive) {
+				continue
+			}
+			content, err := loadNamespaceContent(kubeconfig, discoveryClient, t.name, opts, log)
+			if err != nil && !opts.ContinueOnError {
+				return []Result{}, err
+			}
+			tasks[i].content = content
+			tasks[i].err = err
+		}
+	}
+	if err := checkProtection(tasks, opts); err != nil {
+		return []Result{}, err
+	}
+	if opts.Confirm != nil {
+		if err := confirmTasks(tasks, opts); err != nil {
+			return []Result{}, err
+		}
+	}
+	results, err := runTasks(tasks, opts.Parallel, func(t task, log logger.Logger) ([]Result, error) {
+		results, err := terminateTask(t, opts, log)
+		if err != nil && opts.ContinueOnError {
+			return results, nil // the failure is reported in the results
+		}
+		return results, err
+	}, log)
+	for i := range results {
+		results[i].DryRun = opts.DryRun
+	}
+	if err != nil {
+		return results, err
+	}
+	if failures := failedResults(results); len(failures) > 0 {
+		return results, TerminationFailedError{failures: failures}
+	}
+	return results, nil
+}
+
+// loadTasks returns the tasks to terminate the resource with the given type and name,
+// or all the resources matching the given selectors
+func loadTasks(kubeconfig clientcmd.ClientConfig, resolver *apiResourceResolver, m ResourceMetadata, opts Options, log logger.Logger) ([]task, error) {
+	log.Debug("loading API resource")
+	apiresource, err := resolver.resolveMetadata(m, log)
+	if err != nil {
+		return nil, err
+	}
+	kind := m.displayKind()
+	log.Debug("initializing client")
+	cl, err := newResourceClient(kubeconfig, m.Namespace, apiresource)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := loadResources(cl, m, log)
+	if err != nil {
+		return nil, err
+	}
+	if len(resources) == 0 {
+		log.Info("No resources found")
+		return nil, nil
+	}
+	tasks := make([]task, 0, len(resources))
+	for _, resource := range resources {
+		tasks = append(tasks, task{
+			cl:          cl,
+			apiresource: apiresource,
+			kind:        kind,
+			namespace:   resource.GetNamespace(),
+			name:        resource.GetName(),
+			resource:    resource,
+		})
+	}
+	return tasks, nil
+}
+
+// terminateTask terminates the resource of the given task (and its content first, if it is a namespace to terminate with its content)
+func terminateTask(t task, opts Options, log logger.Logger) ([]Result, error) {
+	if t.err != nil {
+		// resource (or its content) could not be loaded
+		return []Result{failedResult(Result{APIResource: t.apiresource, Kind: t.kind, Namespace: t.namespace, Name: t.name}, t.err, log)}, t.err
+	}
+	results := []Result{}
+	if t.content != nil {
+		// terminate the resources which are blocking the namespace deletion first
+		// (the confirmed ones, even if the termination of the namespace itself was not confirmed)
+		r, err := terminateNamespaceContent(t.name, t.content, opts, log)
+		results = append(results, r...)
 		if err != nil {
-			return err
+			return results, err
 		}
+	}
+	if t.declined {
+		return append(results, declinedResult(t, log)), nil
+	}
+	result, err := terminateResource(t.cl, t.apiresource, t.kind, t.resource, opts, log)
+	if err != nil {
+		// also report what was already done (eg: the finalizers removed and the DELETE request issued before the resource was re-blocked)
+		return append(results, failedResult(result, err, log)), err
+	}
+	return append(results, result), nil
+}
+
+// declinedResult returns the result of the given task whose termination was not confirmed
+func declinedResult(t task, log logger.Logger) Result {
+	log.Info("%s \"%s\" skipped (not confirmed)", t.kind, t.name)
+	return Result{
+		APIResource: t.apiresource,
+		Kind:        t.kind,
+		Namespace:   t.namespace,
+		Name:        t.name,
+		Status:      StatusSkipped,
+		Reason:      "termination was not confirmed",
+	}
+}
+
+// loadResources returns the resource with the given name, or all the resources matching the given selectors
+func loadResources(cl dynamic.ResourceInterface, m ResourceMetadata, log logger.Logger) ([]*unstructured.Unstructured, error) {
+	if m.Name != "" {
 		log.Debug("loading resource '%s/%s' in namespace '%s'", m.Kind, m.Name, m.Namespace)
 		resource, err := cl.Get(m.Name, metav1.GetOptions{})
 		if err != nil {
-			return err
+			return nil, err
 		}
-		log.Debug("removing finalizers on '%s/%s'", resource.GetKind(), resource.GetName())
-		err = removeFinalizers(resource)
-		if err != nil {
-			return err
+		return []*unstructured.Unstructured{resource}, nil
+	}
+	if m.LabelSelector == "" && m.FieldSelector == "" {
+		// do not terminate all resources of the given kind by accident
+		return nil, fmt.Errorf("missing name or selector for resource type '%s'", m.Kind)
+	}
+	log.Debug("listing resources '%s' in namespace '%s' with label selector '%s' and field selector '%s'", m.Kind, m.Namespace, m.LabelSelector, m.FieldSelector)
+	list, err := cl.List(metav1.ListOptions{
+		LabelSelector: m.LabelSelector,
+		FieldSelector: m.FieldSelector,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]*unstructured.Unstructured, 0, len(list.Items))
+	for i := range list.Items {
+		resources = append(resources, &list.Items[i])
+	}
+	return resources, nil
+}
+
+// terminateResource removes all pending finalizers on the given resource and deletes it afterwards
+func terminateResource(cl dynamic.ResourceInterface, apiresource metav1.APIResource, kind string, resource *unstructured.Unstructured, opts Options, log logger.Logger) (Result, error) {
+	name := resource.GetName()
+	result := Result{
+		APIResource: apiresource,
+		Kind:        kind,
+		Namespace:   resource.GetNamespace(),
+		Name:        name,
+	}
+	if err := checkDeletionTimestamp(resource); err != nil && !opts.ForceLive {
+		if !IsNotBeingDeletedError(err) {
+			return result, err
 		}
-		log.Debug("updating '%s/%s'", resource.GetKind(), resource.GetName())
-		resource, err = cl.Update(resource, metav1.UpdateOptions{})
+		log.Info("%s \"%s\" skipped (not being deleted)", kind, name)
+		result.Status = StatusSkipped
+		result.Reason = err.Error()
+		return result, nil
+	}
+	filter := newFinalizerFilter(opts)
+	if opts.DryRun == DryRunClient {
+		return dryRunResource(apiresource, kind, resource, filter, opts, result, log)
+	}
+	if opts.BackupDir != "" && opts.DryRun != DryRunServer {
+		// keep a copy of the whole resource, since it cannot be recovered once its finalizers are removed
+		path, err := backupResource(opts.BackupDir, apiresource, resource)
 		if err != nil {
-			return err
+			return result, fmt.Errorf("unable to backup %s '%s': %w", kind, name, err)
 		}
-		log.Debug("deleting '%s/%s'", resource.GetKind(), resource.GetName())
-		if err := cl.Delete(resource.GetName(), &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
-			// do not ignore errors unless it's a "NotFound" error, which may happen
-			// because the resource was scheduled for deletion and the update to remove its finalizer
-			// (see above) was enough to trigger its deletion
-			return err
-		}
-		log.Info("%s \"%s\" terminated", m.Kind, m.Name)
+		log.Debug("saved '%s/%s' in %s", resource.GetKind(), name, path)
+		result.Backup = path
 	}
-	return nil
-}
-
-func newKubeConfig(r io.Reader) (clientcmd.ClientConfig, error) {
-	d, err := ioutil.ReadAll(r)
+	cleared := []string{}
+	record := newAuditRecord(apiresource, resource)
+	log.Debug("removing finalizers on '%s/%s'", resource.GetKind(), name)
+	removed, resource, err := patchFinalizers(cl, resource, filter, patchOptions(opts), log)
 	if err != nil {
-		return nil, err
+		return result, err
+	}
+	if len(removed) > 0 {
+		cleared = append(cleared, "metadata.finalizers")
+		record.ResourceVersionAfter = resource.GetResourceVersion()
+		record.FinalizersRemoved = removed
+	}
+	if isNamespace(apiresource) {
+		// namespaces are also held by the finalizers in their spec (eg: 'kubernetes'),
+		// which must be cleared through the 'finalize' subresource
+		log.Debug("finalizing namespace '%s'", name)
+		f, r, updated, err := finalizeNamespace(cl, resource, filter, updateOptions(opts))
+		if err != nil && len(removed) > 0 {
+			return result, opts.audit(record, err) // the metadata finalizers were already removed
+		} else if err != nil {
+			return result, err
+		}
+		resource = updated
+		cleared = append(cleared, f...)
+		removed = append(removed, r...)
+		record.ResourceVersionAfter = resource.GetResourceVersion()
+		record.FinalizersRemoved = removed
+	}
+	log.Debug("deleting '%s/%s'", resource.GetKind(), name)
+	err = cl.Delete(name, deleteOptions(opts))
+	switch {
+	case err == nil:
+		record.DeleteResult = DeleteAccepted
+	case errors.IsNotFound(err):
+		// do not ignore errors unless it's a "NotFound" error, which may happen
+		// because the resource was scheduled for deletion and the patch to remove its finalizers
+		// (see above) was enough to trigger its deletion
+		record.DeleteResult = DeleteNotFound
+	default:
+		record.DeleteResult = DeleteFailed
+		if len(removed) > 0 {
+			return result, opts.audit(record, err)
+		}
+		return result, err
+	}
+	if err := opts.audit(record, nil); err != nil {
+		return result, err
+	}
+	if opts.EventRecorder != nil && opts.DryRun != DryRunServer && len(removed) > 0 {
+		opts.EventRecorder.record(kind, resource, removed, log)
+	}
+	result.DeleteIssued = true
+	result.Cleared = cleared
+	result.Finalizers = removed
+	if opts.Wait && opts.DryRun != DryRunServer {
+		// the resource may still exist after the DELETE request, eg: a pod waiting on the kubelet
+		log.Debug("waiting for '%s/%s' to be removed", resource.GetKind(), name)
+		if err := waitForRemoval(cl, name, filter, opts.Timeout, log); err != nil {
+			return result, err
+		}
+		log.Debug("'%s/%s' removed", resource.GetKind(), name)
+	}
+	result.Status = StatusTerminated
+	if filter.selective() {
+		log.Info("%s \"%s\": removed finalizers %v", kind, name, removed)
+	}
+	if isNamespace(apiresource) && len(cleared) > 0 {
+		log.Info("%s \"%s\" terminated (cleared %s)%s", kind, name, strings.Join(cleared, ", "), opts.DryRun.suffix())
+		return result, nil
 	}
-	return clientcmd.NewClientConfigFromBytes(d)
+	log.Info("%s \"%s\" terminated%s", kind, name, opts.DryRun.suffix())
+	return result, nil
 }
 
 func newDiscoveryClient(kubeconfig clientcmd.ClientConfig) (*discovery.DiscoveryClient, error) {
@@ -90,53 +372,150 @@ func newDiscoveryClient(kubeconfig clientcmd.ClientConfig) (*discovery.Discovery
 	return discovery.NewDiscoveryClientForConfig(config)
 }
 
-var apiResourceCache = make(map[string]metav1.APIResource)
-
-// find the API for the given resource type
+// find the API for the given resource type (see `apiResourceResolver` for a cached lookup), among all the versions served
+// by the server. The type can be fully-qualified with its version and group (eg: 'checlusters.v1.org.eclipse.che'),
+// in which case the resource in this version is returned, otherwise the preferred version of the group applies.
+// Returns an `AmbiguousResourceTypeError` if the type matches resources in multiple API groups (see `ambiguous`).
 func lookupAPIResource(n string, cl discovery.DiscoveryInterface, log logger.Logger) (metav1.APIResource, error) {
-	if r, exists := apiResourceCache[n]; exists {
-		return r, nil
+	groups, apiResourceLists, err := cl.ServerGroupsAndResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return metav1.APIResource{}, err
+	} else if err != nil {
+		// some API groups may be unavailable (eg: an aggregated API such as `metrics.k8s.io`), which is one of the most common
+		// reasons why a namespace is stuck. In that case, we can still use the resource lists of the other API groups
+		warnGroupDiscoveryFailures(err.(*discovery.ErrGroupDiscoveryFailed), log)
+	}
+	resources, rerr := servedResources(groups, apiResourceLists)
+	if rerr != nil {
+		return metav1.APIResource{}, rerr
+	}
+	// eg: 'checlusters.v1.org.eclipse.che' is either the 'checlusters' resource in the 'v1' version of the 'org.eclipse.che' group,
+	// or the 'checlusters' resource in the 'v1.org.eclipse.che' group (in its preferred version)
+	gvr, gr := schema.ParseResourceArg(n)
+	if gvr != nil {
+		for _, r := range resources {
+			if r.Group == gvr.Group && r.Version == gvr.Version && matchesResourceName(r, gvr.Resource, log) {
+				return r, nil
+			}
+		}
+	}
+	candidates := []metav1.APIResource{} // first match in each group (ie, in its preferred version if possible)
+	matchedGroups := map[string]bool{}
+	for _, r := range resources {
+		if (gr.Group == "" || r.Group == gr.Group) && !matchedGroups[r.Group] && matchesResourceName(r, gr.Resource, log) {
+			if r.Group == "" {
+				return r, nil // core resources take precedence (same as with kubectl)
+			}
+			matchedGroups[r.Group] = true
+			candidates = append(candidates, r)
+		}
+	}
+	if ambiguous(candidates) {
+		return metav1.APIResource{}, AmbiguousResourceTypeError{
+			name:       n,
+			candidates: candidates,
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[0], nil
 	}
-	apiResourceLists, err := cl.ServerPreferredResources()
 	if err != nil {
-		return metav1.APIResource{}, err
+		return metav1.APIResource{}, fmt.Errorf("unknown resource type: '%s' (some API groups could not be discovered)", n)
+	}
+	return metav1.APIResource{}, fmt.Errorf("unknown resource type: '%s'", n)
+}
+
+// ambiguous returns 'true' if the given resources belong to multiple API groups, unless all of them are
+// Kubernetes API groups, which serve the same objects (eg: 'ingresses' in the 'extensions' and 'networking.k8s.io' groups)
+func ambiguous(candidates []metav1.APIResource) bool {
+	if len(candidates) < 2 {
+		return false
 	}
+	for _, c := range candidates {
+		if strings.Contains(c.Group, ".") && !strings.HasSuffix(c.Group, ".k8s.io") {
+			return true // eg: 'cert-manager.io'
+		}
+	}
+	return false
+}
+
+// matchesResourceName returns 'true' if the given name is the plural or singular name, the kind or a short name of the given API resource
+func matchesResourceName(r metav1.APIResource, n string, log logger.Logger) bool {
+	log.Debug("checking API resource %s", spew.Sdump(r))
+	if r.Name == n || // eg: 'checlusters'
+		strings.ToLower(r.SingularName) == n || // eg: 'checluster'
+		strings.ToLower(r.Kind) == n { // eg: 'checluster'
+		return true
+	}
+	for _, sn := range r.ShortNames {
+		if sn == n {
+			return true
+		}
+	}
+	return false
+}
+
+// servedResources returns the API resources in all the versions served by the server (with their group and version), in the order of
+// the given API groups, and with the resources of the preferred version of each group first
+func servedResources(groups []*metav1.APIGroup, apiResourceLists []*metav1.APIResourceList) ([]metav1.APIResource, error) {
+	lists := make(map[string]*metav1.APIResourceList, len(apiResourceLists))
 	for _, rl := range apiResourceLists {
-		gv, err := schema.ParseGroupVersion(rl.GroupVersion)
-		if err != nil {
-			return metav1.APIResource{}, err
-		}
-		for _, r := range rl.APIResources {
-			log.Debug("checking API resource %s", spew.Sdump(r))
-			if r.Name == n || // eg: 'checlusters'
-				strings.ToLower(r.SingularName) == n || // eg: 'checluster'
-				strings.ToLower(r.Kind) == n || // eg: 'checluster'
-				r.Name+"."+gv.Group == n || // eg: 'checlusters.org.eclipse.che'
-				r.SingularName+"."+gv.Group == n { // eg: 'checluster.org.eclipse.che'
-				r.Group = gv.Group
-				r.Version = gv.Version
-				apiResourceCache[n] = r // keep in cache if we have multiple resource of the same kind to terminate
-				return r, nil
+		lists[rl.GroupVersion] = rl
+	}
+	resources := []metav1.APIResource{}
+	for _, g := range groups {
+		groupVersions := []string{g.PreferredVersion.GroupVersion}
+		for _, v := range g.Versions {
+			if v.GroupVersion != g.PreferredVersion.GroupVersion {
+				groupVersions = append(groupVersions, v.GroupVersion)
 			}
-			for _, sn := range r.ShortNames {
-				if sn == n {
-					r.Group = gv.Group
-					r.Version = gv.Version
-					apiResourceCache[n] = r // keep in cache if we have multiple resource of the same kind to terminate
-					return r, nil
+		}
+		for _, groupVersion := range groupVersions {
+			rl, found := lists[groupVersion]
+			if !found {
+				continue // eg: group version could not be discovered
+			}
+			gv, err := schema.ParseGroupVersion(groupVersion)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range rl.APIResources {
+				if strings.Contains(r.Name, "/") {
+					continue // subresource, eg: 'pods/status'
 				}
+				r.Group = gv.Group
+				r.Version = gv.Version
+				resources = append(resources, r)
 			}
 		}
 	}
-	return metav1.APIResource{}, fmt.Errorf("unknown resource type: '%s'", n)
+	return resources, nil
 }
 
-func newResourceClient(kubeconfig clientcmd.ClientConfig, namespace string, apiresource metav1.APIResource) (dynamic.ResourceInterface, error) {
+// warnGroupDiscoveryFailures logs a warning for each API group which could not be discovered, in a deterministic order
+func warnGroupDiscoveryFailures(err *discovery.ErrGroupDiscoveryFailed, log logger.Logger) {
+	groupVersions := make([]schema.GroupVersion, 0, len(err.Groups))
+	for gv := range err.Groups {
+		groupVersions = append(groupVersions, gv)
+	}
+	sort.Slice(groupVersions, func(i, j int) bool {
+		return groupVersions[i].String() < groupVersions[j].String()
+	})
+	for _, gv := range groupVersions {
+		log.Warn("unable to retrieve the list of resources in '%s': %v", gv, err.Groups[gv])
+	}
+}
+
+func newDynamicClient(kubeconfig clientcmd.ClientConfig) (dynamic.Interface, error) {
 	config, err := kubeconfig.ClientConfig()
 	if err != nil {
 		return nil, err
 	}
-	i, err := dynamic.NewForConfig(config)
+	return dynamic.NewForConfig(config)
+}
+
+func newResourceClient(kubeconfig clientcmd.ClientConfig, namespace string, apiresource metav1.APIResource) (dynamic.ResourceInterface, error) {
+	i, err := newDynamicClient(kubeconfig)
 	if err != nil {
 		return nil, err
 	}
@@ -173,14 +552,31 @@ func checkResource(r *unstructured.Unstructured) error {
 	return nil
 }
 
-func removeFinalizers(r *unstructured.Unstructured) error {
+// removeFinalizers removes the finalizers selected by the given filter in the `metadata.finalizers` of the given resource.
+// Returns the finalizers that were removed (if any)
+func removeFinalizers(r *unstructured.Unstructured, filter finalizerFilter) ([]string, error) {
 	err := checkResource(r)
 	if err != nil && IsMissingFinalizerError(err) {
-		return nil // do not modify the existing resource
+		return nil, nil // do not modify the existing resource
 	} else if err != nil {
-		return err
+		return nil, err
+	}
+	removed, kept := filter.split(r.GetFinalizers())
+	if len(removed) == 0 {
+		return nil, nil // do not modify the existing resource
+	}
+	return removed, unstructured.SetNestedStringSlice(r.Object, kept, "metadata", "finalizers") // override the current value (with an empty slice if all finalizers were removed)
+}
+
+// checkDeletionTimestamp verifies that the given resource is being deleted, ie, it has a `metadata.deletionTimestamp`
+func checkDeletionTimestamp(r *unstructured.Unstructured) error {
+	if r == nil {
+		return fmt.Errorf("missing resource to check")
+	}
+	if r.GetDeletionTimestamp() == nil {
+		return NotBeingDeletedError{name: r.GetName()}
 	}
-	return unstructured.SetNestedSlice(r.Object, []interface{}{}, "metadata", "finalizers") // set an empty slice to override the current value
+	return nil
 }
 
 // MissingFinalizerError the error to return during the resource check when the latter has not 'kubernetes' finalizer
@@ -197,3 +593,43 @@ func IsMissingFinalizerError(err error) bool {
 	_, is := err.(MissingFinalizerError)
 	return is
 }
+
+// NotBeingDeletedError the error to return during the resource check when the latter has no 'deletionTimestamp'
+type NotBeingDeletedError struct {
+	name string
+}
+
+func (e NotBeingDeletedError) Error() string {
+	return fmt.Sprintf("resource '%s' is not being deleted", e.name)
+}
+
+// IsNotBeingDeletedError returns 'true' if the given error is a NotBeingDeletedError
+func IsNotBeingDeletedError(err error) bool {
+	_, is := err.(NotBeingDeletedError)
+	return is
+}
+
+// AmbiguousResourceTypeError the error to return when a resource type matches resources in multiple API groups
+type AmbiguousResourceTypeError struct {
+	name       string
+	candidates []metav1.APIResource
+}
+
+func (e AmbiguousResourceTypeError) Error() string {
+	return fmt.Sprintf("ambiguous resource type: '%s' matches %s (use one of these fully-qualified types instead)", e.name, strings.Join(e.Candidates(), ", "))
+}
+
+// Candidates returns the fully-qualified types of the resources matching the ambiguous type, eg: `certificates.v1alpha2.cert-manager.io`
+func (e AmbiguousResourceTypeError) Candidates() []string {
+	candidates := make([]string, len(e.candidates))
+	for i, c := range e.candidates {
+		candidates[i] = c.Name + "." + c.Version + "." + c.Group
+	}
+	return candidates
+}
+
+// IsAmbiguousResourceTypeError returns 'true' if the given error is an AmbiguousResourceTypeError
+func IsAmbiguousResourceTypeError(err error) bool {
+	_, is := err.(AmbiguousResourceTypeError)
+	return is
+}
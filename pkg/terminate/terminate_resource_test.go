@@ -1,11 +1,13 @@
 package terminate
 
 import (
-	"bytes"
-	"io"
+	"fmt"
+	"io/ioutil"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -16,6 +18,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
 func TestTerminate(t *testing.T) {
@@ -32,12 +35,12 @@ func TestTerminate(t *testing.T) {
 				kubeconfig, server := setup(t)
 				defer server.Close()
 				// when
-				err := Terminate([]ResourceMetadata{
+				_, err := Terminate([]ResourceMetadata{
 					{
 						Kind: "pod",
 						Name: "cookie",
 					},
-				}, kubeconfig, log)
+				}, kubeconfig, Options{}, log)
 				// then
 				require.NoError(t, err)
 			})
@@ -47,18 +50,154 @@ func TestTerminate(t *testing.T) {
 				kubeconfig, server := setup(t)
 				defer server.Close()
 				// when
-				err := Terminate([]ResourceMetadata{
+				_, err := Terminate([]ResourceMetadata{
 					{
 						Kind:      "pod",
 						Name:      "cookie",
 						Namespace: "dessert",
 					},
-				}, kubeconfig, log)
+				}, kubeconfig, Options{}, log)
 				// then
 				require.NoError(t, err)
 			})
 		})
 
+		t.Run("namespace", func(t *testing.T) {
+			// given
+			kubeconfig, server := setup(t)
+			defer server.Close()
+			// when
+			results, err := Terminate([]ResourceMetadata{
+				{
+					Kind: "namespace",
+					Name: "pasta",
+				},
+			}, kubeconfig, Options{}, log)
+			// then
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.Equal(t, StatusTerminated, results[0].Status)
+			assert.Equal(t, []string{"spec.finalizers"}, results[0].Cleared)
+		})
+
+		t.Run("namespace with content", func(t *testing.T) {
+			// given
+			kubeconfig, server := setup(t)
+			defer server.Close()
+			// when
+			results, err := Terminate([]ResourceMetadata{
+				{
+					Kind: "namespace",
+					Name: "pasta",
+				},
+			}, kubeconfig, Options{Content: true}, log)
+			// then
+			require.NoError(t, err)
+			require.Len(t, results, 2)
+			// remaining pod first
+			assert.Equal(t, "pod", results[0].Kind)
+			assert.Equal(t, "pasta", results[0].Namespace)
+			assert.Equal(t, "penne", results[0].Name)
+			assert.Equal(t, StatusTerminated, results[0].Status)
+			assert.Equal(t, []string{"cheesecake"}, results[0].Finalizers)
+			// then the namespace itself
+			assert.Equal(t, "pasta", results[1].Name)
+			assert.Equal(t, StatusTerminated, results[1].Status)
+			assert.Equal(t, []string{"spec.finalizers"}, results[1].Cleared)
+		})
+
+		t.Run("pod with content", func(t *testing.T) {
+			// given
+			kubeconfig, server := setup(t)
+			defer server.Close()
+			// when
+			results, err := Terminate([]ResourceMetadata{
+				{
+					Kind: "pod",
+					Name: "cookie",
+				},
+			}, kubeconfig, Options{Content: true, PropagationPolicy: metav1.DeletePropagationForeground}, log)
+			// then
+			require.NoError(t, err)
+			require.Len(t, results, 1) // no other resource terminated
+			assert.Equal(t, StatusTerminated, results[0].Status)
+		})
+
+		t.Run("pod on unreachable node", func(t *testing.T) {
+
+			t.Run("with zero grace period", func(t *testing.T) {
+				// given
+				kubeconfig, server := setup(t)
+				defer server.Close()
+				gracePeriod := int64(0)
+				// when
+				results, err := Terminate([]ResourceMetadata{
+					{
+						Kind: "pod",
+						Name: "fudge",
+					},
+				}, kubeconfig, Options{GracePeriodSeconds: &gracePeriod, Wait: true, Timeout: 5 * time.Second}, log)
+				// then
+				require.NoError(t, err)
+				require.Len(t, results, 1)
+				assert.Equal(t, StatusTerminated, results[0].Status)
+			})
+
+			t.Run("with default grace period", func(t *testing.T) {
+				// given
+				kubeconfig, server := setup(t)
+				defer server.Close()
+				// when
+				_, err := Terminate([]ResourceMetadata{
+					{
+						Kind: "pod",
+						Name: "fudge",
+					},
+				}, kubeconfig, Options{Wait: true, Timeout: 100 * time.Millisecond}, log)
+				// then
+				require.Error(t, err)
+				assert.True(t, IsWaitTimeoutError(err))
+			})
+		})
+
+		t.Run("resource not being deleted", func(t *testing.T) {
+
+			t.Run("skipped by default", func(t *testing.T) {
+				// given
+				kubeconfig, server := setup(t)
+				defer server.Close()
+				// when
+				results, err := Terminate([]ResourceMetadata{
+					{
+						Kind: "deployment",
+						Name: "latte",
+					},
+				}, kubeconfig, Options{}, log)
+				// then
+				require.NoError(t, err)
+				require.Len(t, results, 1)
+				assert.Equal(t, StatusSkipped, results[0].Status)
+				assert.Equal(t, "resource 'latte' is not being deleted", results[0].Reason)
+			})
+
+			t.Run("terminated with force-live", func(t *testing.T) {
+				// given
+				kubeconfig, server := setup(t)
+				defer server.Close()
+				// when
+				results, err := Terminate([]ResourceMetadata{
+					{
+						Kind: "deployment",
+						Name: "latte",
+					},
+				}, kubeconfig, Options{ForceLive: true}, log)
+				// then
+				require.NoError(t, err)
+				require.Len(t, results, 1)
+				assert.Equal(t, StatusTerminated, results[0].Status)
+			})
+		})
+
 		t.Run("multiple resources", func(t *testing.T) {
 
 			t.Run("in default namespace", func(t *testing.T) {
@@ -66,7 +205,7 @@ func TestTerminate(t *testing.T) {
 				kubeconfig, server := setup(t)
 				defer server.Close()
 				// when
-				err := Terminate([]ResourceMetadata{
+				_, err := Terminate([]ResourceMetadata{
 					{
 						Kind: "pod",
 						Name: "cookie",
@@ -75,7 +214,7 @@ func TestTerminate(t *testing.T) {
 						Kind: "pod",
 						Name: "cookie2",
 					},
-				}, kubeconfig, log)
+				}, kubeconfig, Options{}, log)
 				// then
 				require.NoError(t, err)
 			})
@@ -85,7 +224,7 @@ func TestTerminate(t *testing.T) {
 				kubeconfig, server := setup(t)
 				defer server.Close()
 				// when
-				err := Terminate([]ResourceMetadata{
+				_, err := Terminate([]ResourceMetadata{
 					{
 						Kind:      "pod",
 						Namespace: "dessert",
@@ -96,21 +235,623 @@ func TestTerminate(t *testing.T) {
 						Namespace: "dessert",
 						Name:      "cookie2",
 					},
-				}, kubeconfig, log)
+				}, kubeconfig, Options{}, log)
+				// then
+				require.NoError(t, err)
+			})
+
+			t.Run("in parallel", func(t *testing.T) {
+				// given
+				kubeconfig, server := setup(t)
+				defer server.Close()
+				// when
+				results, err := Terminate([]ResourceMetadata{
+					{
+						Kind: "pod",
+						Name: "cookie",
+					},
+					{
+						Kind: "pod",
+						Name: "cookie2",
+					},
+					{
+						Kind:      "pod",
+						Name:      "cookie",
+						Namespace: "dessert",
+					},
+					{
+						Kind:          "pod",
+						LabelSelector: "app=cookies",
+					},
+				}, kubeconfig, Options{Parallel: 3}, log)
+				// then
+				require.NoError(t, err)
+				require.Len(t, results, 5)
+				// results in the order of the resources
+				names := make([]string, len(results))
+				for i, r := range results {
+					names[i] = r.Namespace + "/" + r.Name
+				}
+				assert.Equal(t, []string{"default/cookie", "default/cookie2", "dessert/cookie", "default/cookie", "default/cookie2"}, names)
+			})
+		})
+
+		t.Run("with backup", func(t *testing.T) {
+
+			t.Run("resource saved before modification", func(t *testing.T) {
+				// given
+				kubeconfig, server := setup(t)
+				defer server.Close()
+				dir, err := ioutil.TempDir("", "backups")
+				require.NoError(t, err)
+				defer os.RemoveAll(dir)
+				// when
+				results, err := Terminate([]ResourceMetadata{
+					{
+						Kind: "pod",
+						Name: "cookie",
+					},
+				}, kubeconfig, Options{BackupDir: dir}, log)
+				// then
+				require.NoError(t, err)
+				require.Len(t, results, 1)
+				assert.Equal(t, filepath.Join(dir, "pods.v1", "default", "cookie.yaml"), results[0].Backup)
+				backups, err := loadBackups(dir)
+				require.NoError(t, err)
+				require.Len(t, backups, 1)
+				assert.Equal(t, "cookie", backups[0].resource.GetName())
+				assert.Equal(t, []string{"cheesecake"}, backups[0].resource.GetFinalizers())
+			})
+
+			t.Run("no backup in dry run", func(t *testing.T) {
+				// given
+				kubeconfig, server := setup(t)
+				defer server.Close()
+				dir, err := ioutil.TempDir("", "backups")
+				require.NoError(t, err)
+				defer os.RemoveAll(dir)
+				// when
+				results, err := Terminate([]ResourceMetadata{
+					{
+						Kind: "pod",
+						Name: "cookie",
+					},
+				}, kubeconfig, Options{BackupDir: dir, DryRun: DryRunServer}, log)
+				// then
+				require.NoError(t, err)
+				require.Len(t, results, 1)
+				assert.Empty(t, results[0].Backup)
+				backups, err := loadBackups(dir)
+				require.NoError(t, err)
+				assert.Empty(t, backups)
+			})
+		})
+
+		t.Run("selected finalizers", func(t *testing.T) {
+
+			t.Run("matching finalizer", func(t *testing.T) {
+				// given
+				kubeconfig, server := setup(t)
+				defer server.Close()
+				// when
+				results, err := Terminate([]ResourceMetadata{
+					{
+						Kind: "pod",
+						Name: "cookie",
+					},
+				}, kubeconfig, Options{Finalizers: []string{"cheese*"}}, log)
+				// then
+				require.NoError(t, err)
+				require.Len(t, results, 1)
+				assert.Equal(t, []string{"cheesecake"}, results[0].Finalizers)
+			})
+
+			t.Run("kept finalizer", func(t *testing.T) {
+				// given
+				kubeconfig, server := setup(t)
+				defer server.Close()
+				// when
+				results, err := Terminate([]ResourceMetadata{
+					{
+						Kind: "pod",
+						Name: "cookie",
+					},
+				}, kubeconfig, Options{KeepFinalizers: []string{"cheesecake"}}, log)
+				// then
+				require.NoError(t, err)
+				require.Len(t, results, 1)
+				assert.Empty(t, results[0].Finalizers)
+			})
+		})
+
+		t.Run("dry run", func(t *testing.T) {
+
+			t.Run("client pod", func(t *testing.T) {
+				// given
+				kubeconfig, server := setup(t)
+				defer server.Close()
+				// when
+				results, err := Terminate([]ResourceMetadata{
+					{
+						Kind: "pod",
+						Name: "cookie",
+					},
+				}, kubeconfig, Options{DryRun: DryRunClient}, log)
+				// then
+				require.NoError(t, err)
+				require.Len(t, results, 1)
+				assert.Equal(t, StatusTerminated, results[0].Status)
+				assert.Equal(t, []string{"metadata.finalizers"}, results[0].Cleared)
+				assert.Equal(t, []string{"cheesecake"}, results[0].Finalizers)
+			})
+
+			t.Run("client namespace", func(t *testing.T) {
+				// given
+				kubeconfig, server := setup(t)
+				defer server.Close()
+				// when
+				results, err := Terminate([]ResourceMetadata{
+					{
+						Kind: "namespace",
+						Name: "pasta",
+					},
+				}, kubeconfig, Options{DryRun: DryRunClient}, log)
+				// then
+				require.NoError(t, err)
+				require.Len(t, results, 1)
+				assert.Equal(t, StatusTerminated, results[0].Status)
+				assert.Equal(t, []string{"spec.finalizers"}, results[0].Cleared)
+				assert.Equal(t, []string{"kubernetes"}, results[0].Finalizers)
+			})
+
+			t.Run("server pod", func(t *testing.T) {
+				// given
+				kubeconfig, server := setup(t)
+				defer server.Close()
+				// when
+				results, err := Terminate([]ResourceMetadata{
+					{
+						Kind: "pod",
+						Name: "cookie",
+					},
+				}, kubeconfig, Options{DryRun: DryRunServer}, log)
+				// then
+				require.NoError(t, err)
+				require.Len(t, results, 1)
+				assert.Equal(t, StatusTerminated, results[0].Status)
+				assert.Equal(t, []string{"cheesecake"}, results[0].Finalizers)
+			})
+		})
+
+		t.Run("with selectors", func(t *testing.T) {
+
+			t.Run("with label selector", func(t *testing.T) {
+				// given
+				kubeconfig, server := setup(t)
+				defer server.Close()
+				// when
+				_, err := Terminate([]ResourceMetadata{
+					{
+						Kind:          "pod",
+						LabelSelector: "app=cookies",
+					},
+				}, kubeconfig, Options{}, log)
+				// then
+				require.NoError(t, err)
+			})
+
+			t.Run("with field selector", func(t *testing.T) {
+				// given
+				kubeconfig, server := setup(t)
+				defer server.Close()
+				// when
+				_, err := Terminate([]ResourceMetadata{
+					{
+						Kind:          "pod",
+						FieldSelector: "metadata.name=cookie",
+					},
+				}, kubeconfig, Options{}, log)
+				// then
+				require.NoError(t, err)
+			})
+
+			t.Run("no match", func(t *testing.T) {
+				// given
+				kubeconfig, server := setup(t)
+				defer server.Close()
+				// when
+				_, err := Terminate([]ResourceMetadata{
+					{
+						Kind:          "pod",
+						LabelSelector: "app=unknown",
+					},
+				}, kubeconfig, Options{}, log)
 				// then
 				require.NoError(t, err)
 			})
 		})
 	})
+
+	t.Run("continue on error", func(t *testing.T) {
+
+		metadata := []ResourceMetadata{
+			{
+				Kind: "pod",
+				Name: "cookie",
+			},
+			{
+				Kind: "pod",
+				Name: "unknown",
+			},
+			{
+				Kind: "pod",
+				Name: "crumble",
+			},
+			{
+				Kind: "foo",
+				Name: "bar",
+			},
+			{
+				Kind: "pod",
+				Name: "cookie2",
+			},
+		}
+
+		t.Run("disabled", func(t *testing.T) {
+			// given
+			kubeconfig, server := setup(t)
+			defer server.Close()
+			// when
+			results, err := Terminate(metadata[2:3], kubeconfig, Options{}, log)
+			// then
+			require.Error(t, err)
+			assert.False(t, IsTerminationFailedError(err))
+			assert.True(t, errors.IsForbidden(err))
+			// the failure is also reported in the results
+			require.Len(t, results, 1)
+			assert.Equal(t, StatusFailed, results[0].Status)
+			assert.Equal(t, err, results[0].Error)
+		})
+
+		t.Run("enabled", func(t *testing.T) {
+			// given
+			kubeconfig, server := setup(t)
+			defer server.Close()
+			for _, parallel := range []int{1, 3} {
+				// when
+				results, err := Terminate(metadata, kubeconfig, Options{ContinueOnError: true, Parallel: parallel}, log)
+				// then
+				require.Error(t, err)
+				require.True(t, IsTerminationFailedError(err))
+				assert.Equal(t, `failed to terminate 2 resource(s): pod "crumble": pods "crumble" is forbidden: User "test" cannot delete resource "pods" in the namespace "default"; foo "bar": unknown resource type: 'foo'`, err.Error())
+				require.Len(t, err.(TerminationFailedError).Failures(), 2)
+				require.Len(t, results, 5)
+				assert.Equal(t, StatusTerminated, results[0].Status)
+				assert.Equal(t, "unknown", results[1].Name)
+				assert.Equal(t, StatusNotFound, results[1].Status)
+				assert.Equal(t, "crumble", results[2].Name)
+				assert.Equal(t, StatusFailed, results[2].Status)
+				assert.True(t, errors.IsForbidden(results[2].Error))
+				assert.Equal(t, "bar", results[3].Name)
+				assert.Equal(t, StatusFailed, results[3].Status)
+				assert.Equal(t, StatusTerminated, results[4].Status)
+			}
+		})
+
+		t.Run("no failure", func(t *testing.T) {
+			// given
+			kubeconfig, server := setup(t)
+			defer server.Close()
+			// when
+			results, err := Terminate(metadata[:2], kubeconfig, Options{ContinueOnError: true}, log)
+			// then
+			require.NoError(t, err)
+			require.Len(t, results, 2)
+			assert.Equal(t, StatusNotFound, results[1].Status)
+		})
+	})
+
+	t.Run("with confirmation", func(t *testing.T) {
+
+		metadata := []ResourceMetadata{
+			{
+				Kind: "pod",
+				Name: "cookie",
+			},
+			{
+				Kind: "pod",
+				Name: "cookie2",
+			},
+			{
+				Kind: "namespace",
+				Name: "pasta",
+			},
+			{
+				Kind: "deploy",
+				Name: "latte",
+			},
+		}
+
+		t.Run("some resources declined", func(t *testing.T) {
+			// given
+			kubeconfig, server := setup(t)
+			defer server.Close()
+			reviews := []Review{}
+			opts := Options{
+				Confirm: func(r []Review) ([]bool, error) {
+					reviews = append(reviews, r...)
+					return []bool{true, false, true}, nil
+				},
+			}
+			// when
+			results, err := Terminate(metadata, kubeconfig, opts, log)
+			// then
+			require.NoError(t, err)
+			// the deployment is not being deleted, so it is not submitted for confirmation
+			require.Len(t, reviews, 3)
+			assert.Equal(t, "cookie", reviews[0].Name)
+			assert.Equal(t, []string{"cheesecake"}, reviews[0].Finalizers)
+			assert.True(t, test.DeletionTimestamp.Equal(reviews[0].DeletionTimestamp))
+			assert.Equal(t, "cookie2", reviews[1].Name)
+			assert.Equal(t, "namespace", reviews[2].Kind)
+			assert.Equal(t, []string{"kubernetes"}, reviews[2].Finalizers)
+			require.Len(t, results, 4)
+			assert.Equal(t, StatusTerminated, results[0].Status)
+			assert.Equal(t, StatusSkipped, results[1].Status)
+			assert.Equal(t, "termination was not confirmed", results[1].Reason)
+			assert.False(t, results[1].DeleteIssued)
+			assert.Equal(t, StatusTerminated, results[2].Status)
+			assert.Equal(t, StatusSkipped, results[3].Status)
+			assert.Equal(t, "resource 'latte' is not being deleted", results[3].Reason)
+		})
+
+		t.Run("confirmation failed", func(t *testing.T) {
+			// given
+			kubeconfig, server := setup(t)
+			defer server.Close()
+			opts := Options{
+				Confirm: func(r []Review) ([]bool, error) {
+					return nil, fmt.Errorf("mock error")
+				},
+			}
+			// when
+			results, err := Terminate(metadata, kubeconfig, opts, log)
+			// then
+			require.EqualError(t, err, "mock error")
+			assert.Empty(t, results)
+		})
+
+		t.Run("namespace content", func(t *testing.T) {
+			// given
+			kubeconfig, server := setup(t)
+			defer server.Close()
+			reviews := []Review{}
+			opts := Options{
+				Content: true,
+				Confirm: func(r []Review) ([]bool, error) {
+					reviews = append(reviews, r...)
+					return []bool{false, true}, nil
+				},
+			}
+			// when
+			results, err := Terminate([]ResourceMetadata{
+				{
+					Kind: "namespace",
+					Name: "pasta",
+				},
+			}, kubeconfig, opts, log)
+			// then
+			require.NoError(t, err)
+			// the content of the namespace is submitted first, in the order of termination
+			require.Len(t, reviews, 2)
+			assert.Equal(t, "pod", reviews[0].Kind)
+			assert.Equal(t, "pasta", reviews[0].Namespace)
+			assert.Equal(t, "penne", reviews[0].Name)
+			assert.Equal(t, "namespace", reviews[1].Kind)
+			assert.Equal(t, "pasta", reviews[1].Name)
+			require.Len(t, results, 2)
+			assert.Equal(t, "penne", results[0].Name)
+			assert.Equal(t, StatusSkipped, results[0].Status)
+			assert.Equal(t, "termination was not confirmed", results[0].Reason)
+			assert.Equal(t, "pasta", results[1].Name)
+			assert.Equal(t, StatusTerminated, results[1].Status)
+		})
+
+		t.Run("namespace content declined with namespace", func(t *testing.T) {
+			// given
+			kubeconfig, server := setup(t)
+			defer server.Close()
+			opts := Options{
+				Content: true,
+				Confirm: func(r []Review) ([]bool, error) {
+					return []bool{true, false}, nil
+				},
+			}
+			// when
+			results, err := Terminate([]ResourceMetadata{
+				{
+					Kind: "namespace",
+					Name: "pasta",
+				},
+			}, kubeconfig, opts, log)
+			// then
+			require.NoError(t, err)
+			require.Len(t, results, 2)
+			// the content which was confirmed is terminated anyways
+			assert.Equal(t, "penne", results[0].Name)
+			assert.Equal(t, StatusTerminated, results[0].Status)
+			assert.Equal(t, "pasta", results[1].Name)
+			assert.Equal(t, StatusSkipped, results[1].Status)
+		})
+	})
+
+	t.Run("with wait", func(t *testing.T) {
+
+		t.Run("resource removed", func(t *testing.T) {
+			// given
+			kubeconfig, server := setup(t)
+			defer server.Close()
+			// when
+			results, err := Terminate([]ResourceMetadata{
+				{
+					Kind: "pod",
+					Name: "donut",
+				},
+			}, kubeconfig, Options{Wait: true, Timeout: 5 * time.Second}, log)
+			// then
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.Equal(t, StatusTerminated, results[0].Status)
+		})
+
+		t.Run("resource removed with kept finalizer", func(t *testing.T) {
+			// given
+			kubeconfig, server := setup(t)
+			defer server.Close()
+			// when
+			results, err := Terminate([]ResourceMetadata{
+				{
+					Kind: "pod",
+					Name: "gelato",
+				},
+			}, kubeconfig, Options{Wait: true, Timeout: 5 * time.Second, KeepFinalizers: []string{"example.com/keep"}}, log)
+			// then
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.Equal(t, StatusTerminated, results[0].Status)
+			assert.Equal(t, []string{"cheesecake"}, results[0].Finalizers)
+		})
+
+		t.Run("resource removed with propagation policy", func(t *testing.T) {
+			for _, policy := range []metav1.DeletionPropagation{metav1.DeletePropagationForeground, metav1.DeletePropagationOrphan} {
+				t.Run(string(policy), func(t *testing.T) {
+					// given
+					kubeconfig, server := setup(t)
+					defer server.Close()
+					// when
+					results, err := Terminate([]ResourceMetadata{
+						{
+							Kind: "pod",
+							Name: "gelato",
+						},
+					}, kubeconfig, Options{Wait: true, Timeout: 5 * time.Second, PropagationPolicy: policy}, log)
+					// then
+					require.NoError(t, err)
+					require.Len(t, results, 1)
+					assert.Equal(t, StatusTerminated, results[0].Status)
+				})
+			}
+		})
+
+		t.Run("resource re-blocked", func(t *testing.T) {
+			// given
+			kubeconfig, server := setup(t)
+			defer server.Close()
+			// when
+			results, err := Terminate([]ResourceMetadata{
+				{
+					Kind: "pod",
+					Name: "eclair",
+				},
+			}, kubeconfig, Options{Wait: true, Timeout: 5 * time.Second}, log)
+			// then
+			require.Error(t, err)
+			require.True(t, IsReblockedError(err))
+			assert.Equal(t, []string{"example.com/protection"}, err.(ReblockedError).Finalizers())
+			// the finalizers were removed and the resource was deleted before it was re-blocked
+			require.Len(t, results, 1)
+			assert.Equal(t, StatusReblocked, results[0].Status)
+			assert.Equal(t, err, results[0].Error)
+			assert.True(t, results[0].DeleteIssued)
+			assert.NotEmpty(t, results[0].Finalizers)
+		})
+
+		t.Run("resource re-blocked with continue on error", func(t *testing.T) {
+			// given
+			kubeconfig, server := setup(t)
+			defer server.Close()
+			// when
+			results, err := Terminate([]ResourceMetadata{
+				{
+					Kind: "pod",
+					Name: "eclair",
+				},
+				{
+					Kind: "pod",
+					Name: "donut",
+				},
+			}, kubeconfig, Options{Wait: true, Timeout: 5 * time.Second, ContinueOnError: true}, log)
+			// then
+			require.Error(t, err)
+			require.True(t, IsTerminationFailedError(err))
+			require.Len(t, results, 2)
+			assert.Equal(t, StatusReblocked, results[0].Status)
+			assert.Equal(t, StatusTerminated, results[1].Status)
+		})
+
+		t.Run("timeout", func(t *testing.T) {
+			// given
+			kubeconfig, server := setup(t)
+			defer server.Close()
+			// when
+			results, err := Terminate([]ResourceMetadata{
+				{
+					Kind:      "pod",
+					Namespace: "dessert",
+					Name:      "cookie2",
+				},
+			}, kubeconfig, Options{Wait: true, Timeout: 100 * time.Millisecond}, log)
+			// then
+			require.Error(t, err)
+			assert.True(t, IsWaitTimeoutError(err))
+			require.Len(t, results, 1)
+			assert.Equal(t, StatusFailed, results[0].Status)
+			assert.Equal(t, err, results[0].Error)
+			assert.True(t, results[0].DeleteIssued)
+		})
+
+		t.Run("no wait in dry run", func(t *testing.T) {
+			// given
+			kubeconfig, server := setup(t)
+			defer server.Close()
+			// when
+			results, err := Terminate([]ResourceMetadata{
+				{
+					Kind: "pod",
+					Name: "eclair",
+				},
+			}, kubeconfig, Options{Wait: true, Timeout: 5 * time.Second, DryRun: DryRunServer}, log)
+			// then
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.Equal(t, StatusTerminated, results[0].Status)
+		})
+	})
+
+	t.Run("failures", func(t *testing.T) {
+
+		t.Run("missing name and selector", func(t *testing.T) {
+			// given
+			kubeconfig, server := setup(t)
+			defer server.Close()
+			// when
+			_, err := Terminate([]ResourceMetadata{
+				{
+					Kind: "pod",
+				},
+			}, kubeconfig, Options{}, log)
+			// then
+			require.Error(t, err)
+			assert.Equal(t, "missing name or selector for resource type 'pod'", err.Error())
+		})
+	})
 }
 
 func TestLookupAPIResource(t *testing.T) {
 
 	// given
 	log := logger.NewLogger(os.Stdout, 1) // includes 'debug' messages
-	kubeconfigContent, server := setup(t)
-	kubeconfig, err := newKubeConfig(kubeconfigContent)
-	require.NoError(t, err)
+	kubeconfig, server := setup(t)
 	defer server.Close()
 	client, err := newDiscoveryClient(kubeconfig)
 	require.NoError(t, err)
@@ -130,6 +871,7 @@ func TestLookupAPIResource(t *testing.T) {
 					ShortNames: []string{"ns"},
 					Namespaced: false,
 					Version:    "v1",
+					Verbs:      test.Verbs,
 				}, r)
 			})
 
@@ -144,6 +886,7 @@ func TestLookupAPIResource(t *testing.T) {
 					ShortNames: []string{"ns"},
 					Namespaced: false,
 					Version:    "v1",
+					Verbs:      test.Verbs,
 				}, r)
 			})
 		})
@@ -163,6 +906,7 @@ func TestLookupAPIResource(t *testing.T) {
 					ShortNames:   []string{"ct"},
 					Namespaced:   true,
 					Kind:         "CustomType",
+					Verbs:        test.Verbs,
 				}, r)
 			})
 
@@ -179,6 +923,7 @@ func TestLookupAPIResource(t *testing.T) {
 					ShortNames:   []string{"ct"},
 					Namespaced:   true,
 					Kind:         "CustomType",
+					Verbs:        test.Verbs,
 				}, r)
 			})
 
@@ -195,6 +940,7 @@ func TestLookupAPIResource(t *testing.T) {
 					ShortNames:   []string{"ct"},
 					Namespaced:   true,
 					Kind:         "CustomType",
+					Verbs:        test.Verbs,
 				}, r)
 			})
 
@@ -211,6 +957,7 @@ func TestLookupAPIResource(t *testing.T) {
 					ShortNames:   []string{"ct"},
 					Namespaced:   true,
 					Kind:         "CustomType",
+					Verbs:        test.Verbs,
 				}, r)
 			})
 
@@ -227,6 +974,7 @@ func TestLookupAPIResource(t *testing.T) {
 					ShortNames:   []string{"ct"},
 					Namespaced:   true,
 					Kind:         "CustomType",
+					Verbs:        test.Verbs,
 				}, r)
 			})
 
@@ -243,13 +991,82 @@ func TestLookupAPIResource(t *testing.T) {
 					ShortNames:   []string{"ct"},
 					Namespaced:   true,
 					Kind:         "CustomType",
+					Verbs:        test.Verbs,
 				}, r)
 			})
+
+			t.Run("by fully-qualified plural name in preferred version", func(t *testing.T) {
+				// when
+				r, err := lookupAPIResource("customtypes.v1beta1.customdomain", client, log)
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "customdomain", r.Group)
+				assert.Equal(t, "v1beta1", r.Version)
+				assert.Equal(t, "customtypes", r.Name)
+			})
+
+			t.Run("by fully-qualified singular name in other served version", func(t *testing.T) {
+				// when
+				r, err := lookupAPIResource("customtype.v1alpha1.customdomain", client, log)
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, "customdomain", r.Group)
+				assert.Equal(t, "v1alpha1", r.Version)
+				assert.Equal(t, "customtypes", r.Name)
+			})
+		})
+
+		t.Run("resource type in API group by fully-qualified name", func(t *testing.T) {
+			// when
+			r, err := lookupAPIResource("deployments.v1.apps", client, log)
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "apps", r.Group)
+			assert.Equal(t, "v1", r.Version)
+			assert.Equal(t, "Deployment", r.Kind)
+		})
+	})
+
+	t.Run("same names in multiple groups", func(t *testing.T) {
+
+		t.Run("core resource type takes precedence", func(t *testing.T) {
+			// when
+			r, err := lookupAPIResource("po", client, log)
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "", r.Group)
+			assert.Equal(t, "pods", r.Name)
+		})
+
+		t.Run("qualified short name", func(t *testing.T) {
+			// when
+			r, err := lookupAPIResource("ck.bakery.example.com", client, log)
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "bakery.example.com", r.Group)
+			assert.Equal(t, "cakes", r.Name)
+		})
+
+		t.Run("ambiguous short name", func(t *testing.T) {
+			// when
+			_, err := lookupAPIResource("ck", client, log)
+			// then
+			require.Error(t, err)
+			require.True(t, IsAmbiguousResourceTypeError(err))
+			assert.Equal(t, []string{"cakes.v1.bakery.example.com", "cupcakes.v1alpha1.patisserie.example.com"}, err.(AmbiguousResourceTypeError).Candidates())
+			assert.Equal(t, "ambiguous resource type: 'ck' matches cakes.v1.bakery.example.com, cupcakes.v1alpha1.patisserie.example.com (use one of these fully-qualified types instead)", err.Error())
 		})
 	})
 
 	t.Run("failures", func(t *testing.T) {
 
+		t.Run("unknown version", func(t *testing.T) {
+			// when
+			_, err := lookupAPIResource("customtypes.v2.customdomain", client, log)
+			// then
+			require.EqualError(t, err, "unknown resource type: 'customtypes.v2.customdomain'")
+		})
+
 		t.Run("unknown resource type", func(t *testing.T) {
 			// when
 			_, err := lookupAPIResource("unknown", client, log)
@@ -259,14 +1076,39 @@ func TestLookupAPIResource(t *testing.T) {
 		})
 
 	})
+
+	t.Run("with unavailable API group", func(t *testing.T) {
+		// given
+		server := test.NewServer(t, "metrics.k8s.io")
+		defer server.Close()
+		kubeconfig, err := clientcmd.NewClientConfigFromBytes(test.NewKubeConfigContent(t, server.URL))
+		require.NoError(t, err)
+		client, err := newDiscoveryClient(kubeconfig)
+		require.NoError(t, err)
+
+		t.Run("resource type in available API group", func(t *testing.T) {
+			// when
+			r, err := lookupAPIResource("deploy", client, log)
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "apps", r.Group)
+			assert.Equal(t, "deployments", r.Name)
+		})
+
+		t.Run("unknown resource type", func(t *testing.T) {
+			// when
+			_, err := lookupAPIResource("podmetrics", client, log)
+			// then
+			require.Error(t, err)
+			assert.Equal(t, "unknown resource type: 'podmetrics' (some API groups could not be discovered)", err.Error())
+		})
+	})
 }
 
 func TestFetchResource(t *testing.T) {
 
 	// given
-	kubeconfigContent, server := setup(t)
-	kubeconfig, err := newKubeConfig(kubeconfigContent)
-	require.NoError(t, err)
+	kubeconfig, server := setup(t)
 	defer server.Close()
 
 	t.Run("ok", func(t *testing.T) {
@@ -292,7 +1134,9 @@ func TestFetchResource(t *testing.T) {
 					Kind:       "Namespace",
 				},
 				ObjectMeta: metav1.ObjectMeta{
-					Name: "pasta",
+					Name:              "pasta",
+					DeletionTimestamp: &test.DeletionTimestamp,
+					ResourceVersion:   "1",
 				},
 				Spec: corev1.NamespaceSpec{
 					Finalizers: []corev1.FinalizerName{
@@ -301,6 +1145,14 @@ func TestFetchResource(t *testing.T) {
 				},
 				Status: corev1.NamespaceStatus{
 					Phase: "Terminating",
+					Conditions: []corev1.NamespaceCondition{
+						{
+							Type:    corev1.NamespaceFinalizersRemaining,
+							Status:  corev1.ConditionTrue,
+							Reason:  "SomeFinalizersRemain",
+							Message: "Some content in the namespace has finalizers remaining: cheesecake in 1 resource instances",
+						},
+					},
 				},
 			})
 			require.NoError(t, err)
@@ -392,6 +1244,56 @@ func TestCheckResource(t *testing.T) {
 	})
 }
 
+func TestCheckDeletionTimestamp(t *testing.T) {
+
+	t.Run("pod being deleted", func(t *testing.T) {
+		// given
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&corev1.Pod{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Pod",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         "pasta",
+				Name:              "cookie",
+				DeletionTimestamp: &test.DeletionTimestamp,
+			},
+		})
+		require.NoError(t, err)
+		actual := &unstructured.Unstructured{
+			Object: object,
+		}
+		// when
+		err = checkDeletionTimestamp(actual)
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("pod not being deleted", func(t *testing.T) {
+		// given
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&corev1.Pod{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Pod",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "pasta",
+				Name:      "cookie",
+			},
+		})
+		require.NoError(t, err)
+		actual := &unstructured.Unstructured{
+			Object: object,
+		}
+		// when
+		err = checkDeletionTimestamp(actual)
+		// then
+		require.Error(t, err)
+		assert.IsType(t, NotBeingDeletedError{}, err)
+		assert.Equal(t, "resource 'cookie' is not being deleted", err.Error())
+	})
+}
+
 func TestRemoveFinalizers(t *testing.T) {
 
 	t.Run("pod with finalizer", func(t *testing.T) {
@@ -418,12 +1320,41 @@ func TestRemoveFinalizers(t *testing.T) {
 			Object: object,
 		}
 		// when
-		err = removeFinalizers(actual)
+		removed, err := removeFinalizers(actual, finalizerFilter{})
 		// then
 		require.NoError(t, err)
+		assert.Equal(t, []string{"custom"}, removed)
 		assert.Empty(t, actual.GetFinalizers())
 	})
 
+	t.Run("pod with selected finalizers", func(t *testing.T) {
+		// given
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&corev1.Pod{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Pod",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "pasta",
+				Name:      "cookie",
+				Finalizers: []string{
+					"foo.example.com/cleanup",
+					"kubernetes.io/pvc-protection",
+				},
+			},
+		})
+		require.NoError(t, err)
+		actual := &unstructured.Unstructured{
+			Object: object,
+		}
+		// when
+		removed, err := removeFinalizers(actual, finalizerFilter{keep: []string{"kubernetes.io/*"}})
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"foo.example.com/cleanup"}, removed)
+		assert.Equal(t, []string{"kubernetes.io/pvc-protection"}, actual.GetFinalizers())
+	})
+
 	t.Run("pod without finalizer", func(t *testing.T) {
 		// given
 		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&corev1.Pod{
@@ -446,14 +1377,39 @@ func TestRemoveFinalizers(t *testing.T) {
 			Object: object,
 		}
 		// when
-		err = removeFinalizers(actual)
+		removed, err := removeFinalizers(actual, finalizerFilter{})
 		require.NoError(t, err)
+		assert.Empty(t, removed)
 		assert.Empty(t, actual.GetFinalizers())
 	})
 }
 
-func setup(t *testing.T) (io.Reader, *httptest.Server) {
+func setup(t *testing.T) (clientcmd.ClientConfig, *httptest.Server) {
 	server := test.NewServer(t)
-	kubeconfigContent := bytes.NewBuffer(test.NewKubeConfigContent(t, server.URL))
-	return kubeconfigContent, server
+	kubeconfig, err := clientcmd.NewClientConfigFromBytes(test.NewKubeConfigContent(t, server.URL))
+	require.NoError(t, err)
+	return kubeconfig, server
+}
+
+func TestAmbiguous(t *testing.T) {
+
+	t.Run("single group", func(t *testing.T) {
+		assert.False(t, ambiguous([]metav1.APIResource{
+			{Group: "cert-manager.io", Name: "certificates"},
+		}))
+	})
+
+	t.Run("kubernetes groups", func(t *testing.T) {
+		assert.False(t, ambiguous([]metav1.APIResource{
+			{Group: "extensions", Name: "ingresses"},
+			{Group: "networking.k8s.io", Name: "ingresses"},
+		}))
+	})
+
+	t.Run("custom groups", func(t *testing.T) {
+		assert.True(t, ambiguous([]metav1.APIResource{
+			{Group: "cert-manager.io", Name: "certificates"},
+			{Group: "networking.internal.knative.dev", Name: "certificates"},
+		}))
+	})
 }
@@ -0,0 +1,149 @@
+package terminate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+// waitForRemoval watches the resource with the given name until it is actually removed, or until the given timeout expires
+// (if greater than 0).
+// Returns a `ReblockedError` if the resource is blocked again by some finalizers matching the given filter
+// (eg: re-added by a controller), or a `WaitTimeoutError` if the resource still exists when the timeout expires.
+func waitForRemoval(cl dynamic.ResourceInterface, name string, filter finalizerFilter, timeout time.Duration, log logger.Logger) error {
+	var expired <-chan time.Time // never fires if there is no timeout
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	for {
+		select {
+		case <-expired:
+			return WaitTimeoutError{name: name}
+		default:
+		}
+		resource, err := cl.Get(name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := checkReblocked(resource, filter); err != nil {
+			return err
+		}
+		log.Debug("watching '%s/%s' from version %s", resource.GetKind(), name, resource.GetResourceVersion())
+		removed, err := watchRemoval(cl, resource, filter, expired)
+		if err != nil || removed {
+			return err
+		}
+		// the watch was closed (or expired) before the resource was removed, so let's start over
+		log.Debug("restarting the watch on '%s/%s'", resource.GetKind(), name)
+	}
+}
+
+// watchRemoval watches the given resource until it is removed (in which case it returns 'true'), until the watch is closed
+// (in which case it returns 'false'), or until the given channel fires
+func watchRemoval(cl dynamic.ResourceInterface, resource *unstructured.Unstructured, filter finalizerFilter, expired <-chan time.Time) (bool, error) {
+	w, err := cl.Watch(metav1.ListOptions{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", resource.GetName()).String(),
+		ResourceVersion: resource.GetResourceVersion(),
+	})
+	if err != nil {
+		return false, err
+	}
+	defer w.Stop()
+	for {
+		select {
+		case <-expired:
+			return false, WaitTimeoutError{name: resource.GetName()}
+		case e, open := <-w.ResultChan():
+			if !open {
+				return false, nil
+			}
+			switch e.Type {
+			case watch.Deleted:
+				return true, nil
+			case watch.Added, watch.Modified:
+				if r, ok := e.Object.(*unstructured.Unstructured); ok {
+					if err := checkReblocked(r, filter); err != nil {
+						return false, err
+					}
+				}
+			case watch.Error:
+				// eg: the resource version is too old, in which case the watch must be restarted
+				return false, nil
+			}
+		}
+	}
+}
+
+// checkReblocked returns a `ReblockedError` if the given resource has finalizers which the given filter removes
+// (either in its metadata, or in its spec if it is a namespace), ie, finalizers which were removed and added again.
+// The finalizers which were kept, and the ones which the API server adds for the propagation policy of the deletion
+// (`foregroundDeletion` and `orphan`) do not block the resource again.
+func checkReblocked(resource *unstructured.Unstructured, filter finalizerFilter) error {
+	finalizers := resource.GetFinalizers()
+	if resource.GetAPIVersion() == "v1" && resource.GetKind() == "Namespace" {
+		specFinalizers, _, _ := unstructured.NestedStringSlice(resource.Object, "spec", "finalizers")
+		finalizers = append(finalizers, specFinalizers...)
+	}
+	reblocking := []string{}
+	for _, f := range finalizers {
+		if f == metav1.FinalizerDeleteDependents || f == metav1.FinalizerOrphanDependents || !filter.removes(f) {
+			continue
+		}
+		reblocking = append(reblocking, f)
+	}
+	if len(reblocking) > 0 {
+		return ReblockedError{
+			name:       resource.GetName(),
+			finalizers: reblocking,
+		}
+	}
+	return nil
+}
+
+// ReblockedError the error to return when a terminated resource was blocked again by some finalizers (eg: re-added by a controller)
+type ReblockedError struct {
+	name       string
+	finalizers []string
+}
+
+func (e ReblockedError) Error() string {
+	return fmt.Sprintf("resource '%s' was re-blocked by finalizer(s) %v", e.name, e.finalizers)
+}
+
+// Finalizers returns the finalizers which block the resource
+func (e ReblockedError) Finalizers() []string {
+	return e.finalizers
+}
+
+// IsReblockedError returns 'true' if the given error is a ReblockedError
+func IsReblockedError(err error) bool {
+	_, is := err.(ReblockedError)
+	return is
+}
+
+// WaitTimeoutError the error to return when a terminated resource still exists after the timeout expired
+type WaitTimeoutError struct {
+	name string
+}
+
+func (e WaitTimeoutError) Error() string {
+	return fmt.Sprintf("timed out waiting for resource '%s' to be removed", e.name)
+}
+
+// IsWaitTimeoutError returns 'true' if the given error is a WaitTimeoutError
+func IsWaitTimeoutError(err error) bool {
+	_, is := err.(WaitTimeoutError)
+	return is
+}
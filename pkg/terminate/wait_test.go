@@ -0,0 +1,94 @@
+package terminate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCheckReblocked(t *testing.T) {
+
+	t.Run("pod without finalizer", func(t *testing.T) {
+		// given
+		r := &unstructured.Unstructured{}
+		r.SetAPIVersion("v1")
+		r.SetKind("Pod")
+		r.SetName("cookie")
+		// when
+		err := checkReblocked(r, finalizerFilter{})
+		// then
+		assert.NoError(t, err)
+	})
+
+	t.Run("pod with finalizer", func(t *testing.T) {
+		// given
+		r := &unstructured.Unstructured{}
+		r.SetAPIVersion("v1")
+		r.SetKind("Pod")
+		r.SetName("cookie")
+		r.SetFinalizers([]string{"cheesecake"})
+		// when
+		err := checkReblocked(r, finalizerFilter{})
+		// then
+		require.Error(t, err)
+		assert.True(t, IsReblockedError(err))
+		assert.Equal(t, "resource 'cookie' was re-blocked by finalizer(s) [cheesecake]", err.Error())
+	})
+
+	t.Run("namespace with spec finalizer", func(t *testing.T) {
+		// given
+		r := &unstructured.Unstructured{}
+		r.SetAPIVersion("v1")
+		r.SetKind("Namespace")
+		r.SetName("pasta")
+		err := unstructured.SetNestedStringSlice(r.Object, []string{"kubernetes"}, "spec", "finalizers")
+		require.NoError(t, err)
+		// when
+		err = checkReblocked(r, finalizerFilter{})
+		// then
+		require.Error(t, err)
+		assert.Equal(t, []string{"kubernetes"}, err.(ReblockedError).Finalizers())
+	})
+
+	t.Run("pod with kept finalizer", func(t *testing.T) {
+		// given
+		r := &unstructured.Unstructured{}
+		r.SetAPIVersion("v1")
+		r.SetKind("Pod")
+		r.SetName("cookie")
+		r.SetFinalizers([]string{"example.com/keep", "cheesecake"})
+		// when
+		err := checkReblocked(r, finalizerFilter{keep: []string{"example.com/*"}})
+		// then
+		require.Error(t, err)
+		assert.Equal(t, []string{"cheesecake"}, err.(ReblockedError).Finalizers())
+	})
+
+	t.Run("pod with finalizer which is not removed", func(t *testing.T) {
+		// given
+		r := &unstructured.Unstructured{}
+		r.SetAPIVersion("v1")
+		r.SetKind("Pod")
+		r.SetName("cookie")
+		r.SetFinalizers([]string{"example.com/other"})
+		// when
+		err := checkReblocked(r, finalizerFilter{remove: []string{"cheesecake"}})
+		// then
+		assert.NoError(t, err)
+	})
+
+	t.Run("pod with propagation policy finalizers", func(t *testing.T) {
+		// given
+		r := &unstructured.Unstructured{}
+		r.SetAPIVersion("v1")
+		r.SetKind("Pod")
+		r.SetName("cookie")
+		r.SetFinalizers([]string{"foregroundDeletion", "orphan"})
+		// when
+		err := checkReblocked(r, finalizerFilter{})
+		// then
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,113 @@
+package terminate
+
+import (
+	"strings"
+
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// FinalizedResource a resource which holds finalizers in its metadata
+type FinalizedResource struct {
+	APIResource       metav1.APIResource
+	Namespace         string
+	Name              string
+	Finalizers        []string
+	DeletionTimestamp *metav1.Time
+}
+
+// typeName returns the name of the given API resource type, qualified with its group (if applicable),
+// eg: `pods` or `deployments.apps`
+func typeName(apiresource metav1.APIResource) string {
+	if apiresource.Group == "" {
+		return apiresource.Name
+	}
+	return apiresource.Name + "." + apiresource.Group
+}
+
+// listableResources returns all the API resource types which support the `list` verb (only the namespaced ones if specified).
+// API groups which could not be discovered are skipped (with a warning)
+func listableResources(cl discovery.DiscoveryInterface, namespaced bool, log logger.Logger) ([]metav1.APIResource, error) {
+	apiResourceLists, err := cl.ServerPreferredResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, err
+	} else if err != nil {
+		warnGroupDiscoveryFailures(err.(*discovery.ErrGroupDiscoveryFailed), log)
+	}
+	resources := []metav1.APIResource{}
+	for _, rl := range apiResourceLists {
+		gv, err := schema.ParseGroupVersion(rl.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rl.APIResources {
+			if strings.Contains(r.Name, "/") || // skip subresources, eg: 'pods/status'
+				(namespaced && !r.Namespaced) ||
+				!hasVerb(r, "list") {
+				continue
+			}
+			r.Group = gv.Group
+			r.Version = gv.Version
+			resources = append(resources, r)
+		}
+	}
+	return resources, nil
+}
+
+func hasVerb(apiresource metav1.APIResource, verb string) bool {
+	for _, v := range apiresource.Verbs {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}
+
+// findFinalizedResources returns all the resources which hold finalizers in the given namespace (or in all namespaces if empty),
+// among the given API resource types. Resource types which cannot be listed are skipped (with a warning)
+func findFinalizedResources(cl dynamic.Interface, apiresources []metav1.APIResource, namespace string, log logger.Logger) []FinalizedResource {
+	result := []FinalizedResource{}
+	walkResources(cl, apiresources, namespace, func(apiresource metav1.APIResource, item unstructured.Unstructured) {
+		if len(item.GetFinalizers()) == 0 {
+			return
+		}
+		result = append(result, FinalizedResource{
+			APIResource:       apiresource,
+			Namespace:         item.GetNamespace(),
+			Name:              item.GetName(),
+			Finalizers:        item.GetFinalizers(),
+			DeletionTimestamp: item.GetDeletionTimestamp(),
+		})
+	}, log)
+	return result
+}
+
+// walkResources calls `visit` on each resource in the given namespace (or in all namespaces if empty),
+// among the given API resource types. Resource types which cannot be listed are skipped (with a warning)
+func walkResources(cl dynamic.Interface, apiresources []metav1.APIResource, namespace string, visit func(metav1.APIResource, unstructured.Unstructured), log logger.Logger) {
+	for _, apiresource := range apiresources {
+		gvr := schema.GroupVersionResource{
+			Group:    apiresource.Group,
+			Version:  apiresource.Version,
+			Resource: apiresource.Name,
+		}
+		var rc dynamic.ResourceInterface = cl.Resource(gvr)
+		if apiresource.Namespaced && namespace != "" {
+			rc = cl.Resource(gvr).Namespace(namespace)
+		}
+		log.Debug("listing '%s' in namespace '%s'", typeName(apiresource), namespace)
+		list, err := rc.List(metav1.ListOptions{})
+		if err != nil {
+			log.Warn("unable to list '%s': %v", typeName(apiresource), err)
+			continue
+		}
+		for _, item := range list.Items {
+			visit(apiresource, item)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package terminate
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xcoulon/kubectl-terminate/pkg/logger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTypeName(t *testing.T) {
+	assert.Equal(t, "pods", typeName(metav1.APIResource{Version: "v1", Name: "pods"}))
+	assert.Equal(t, "deployments.apps", typeName(metav1.APIResource{Group: "apps", Version: "v1", Name: "deployments"}))
+}
+
+func TestListableResources(t *testing.T) {
+
+	// given
+	log := logger.NewLogger(os.Stdout, 0)
+	kubeconfig, server := setup(t)
+	defer server.Close()
+	client, err := newDiscoveryClient(kubeconfig)
+	require.NoError(t, err)
+
+	t.Run("all resources", func(t *testing.T) {
+		// when
+		resources, err := listableResources(client, false, log)
+		// then
+		require.NoError(t, err)
+		names := []string{}
+		for _, r := range resources {
+			names = append(names, typeName(r))
+		}
+		assert.ElementsMatch(t, []string{"namespaces", "pods", "customtypes.customdomain", "deployments.apps"}, names)
+	})
+
+	t.Run("namespaced resources", func(t *testing.T) {
+		// when
+		resources, err := listableResources(client, true, log)
+		// then
+		require.NoError(t, err)
+		names := []string{}
+		for _, r := range resources {
+			names = append(names, typeName(r))
+		}
+		assert.ElementsMatch(t, []string{"pods", "customtypes.customdomain", "deployments.apps"}, names)
+	})
+}
+
+func TestFindFinalizedResources(t *testing.T) {
+
+	// given
+	log := logger.NewLogger(os.Stdout, 0)
+	kubeconfig, server := setup(t)
+	defer server.Close()
+	discoveryClient, err := newDiscoveryClient(kubeconfig)
+	require.NoError(t, err)
+	dynamicClient, err := newDynamicClient(kubeconfig)
+	require.NoError(t, err)
+	apiresources, err := listableResources(discoveryClient, true, log)
+	require.NoError(t, err)
+
+	// when
+	resources := findFinalizedResources(dynamicClient, apiresources, "pasta", log)
+
+	// then
+	require.Len(t, resources, 1)
+	assert.Equal(t, "pasta", resources[0].Namespace)
+	assert.Equal(t, "penne", resources[0].Name)
+	assert.Equal(t, []string{"cheesecake"}, resources[0].Finalizers)
+	assert.NotNil(t, resources[0].DeletionTimestamp)
+}
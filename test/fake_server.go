@@ -1,257 +1,311 @@
 package test
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	jsonpatch "github.com/evanphx/json-patch"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 )
 
+// DeletionTimestamp the deletion timestamp of the predefined resources which are being deleted
+var DeletionTimestamp = metav1.Date(2020, time.March, 1, 12, 0, 0, 0, time.UTC)
+
 // NewServer returns a new HTTP Server which supports:
 // - calls to `/api`
 // - calls to `/apis`
 // - calls on some predefined resources
+// - calls on the `finalize` subresource of the predefined namespaces
+// - JSON patch calls on the predefined resources
+// - list calls on pods in the `default` namespace, with some predefined label and field selectors
+// - list calls on all resource types in the `pasta` namespace, and in all namespaces
+// - POST calls to create resources, with a 409 response if a predefined resource with the same name exists
+// - 403 responses on DELETE calls on the `crumble` pod
+// - DELETE calls on the `fudge` pod (on an unreachable node), which is only removed with a zero grace period
+// - watch calls on pods, where the `donut` pod is removed and the `eclair` pod is blocked again after they were deleted
+// - DELETE calls on the `gelato` pod, which keeps its remaining finalizers and the one of the propagation policy (if any)
+// - 503 responses on calls to the given unavailable API groups (which are listed in the response to `/apis`)
 // - 404 responses otherwise
 // see https://github.com/kubernetes/client-go/blob/master/discovery/discovery_client_test.go
-func NewServer(t *testing.T) *httptest.Server {
+func NewServer(t *testing.T, unavailableGroups ...string) *httptest.Server {
+	// the `muffin` pod is concurrently modified after it was first fetched
+	muffinVersion := 1
+	muffinPatches := 0
+	// the `donut` and `eclair` pods have no finalizer anymore once they were deleted,
+	// and the `fudge` pod does not exist anymore once it was forcefully deleted
+	deleted := map[string]bool{}
+	// the finalizers of the `gelato` pod once it was patched and deleted
+	gelatoFinalizers := []string{}
+	lock := sync.Mutex{}
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		var response interface{}
 		fmt.Printf("processing %s %s\n", req.Method, req.URL)
 		switch req.Method {
 		case "GET":
-			switch req.URL.Path {
-			case "/api/v1":
-				response = &metav1.APIResourceList{
-					GroupVersion: "v1",
-					APIResources: []metav1.APIResource{
-						{
-							Name:       "namespaces",
-							ShortNames: []string{"ns"},
-							Namespaced: false,
-							Kind:       "Namespace",
-						},
-						{
-							Name:         "pods",
-							SingularName: "pod",
-							ShortNames:   []string{"po"},
-							Namespaced:   true,
-							Kind:         "Pod",
-						},
-					},
-				}
-			case "/api":
-				response = &metav1.APIVersions{
-					Versions: []string{
-						"v1",
-					},
-				}
-			case "/apis":
-				response = &metav1.APIGroupList{
-					Groups: []metav1.APIGroup{
-						{
-							Name: "customdomain",
-							Versions: []metav1.GroupVersionForDiscovery{
-								{
-									GroupVersion: "customdomain/v1beta1",
-									Version:      "v1beta1",
-								},
-							},
-						},
-						{
-							Name: "apps",
-							Versions: []metav1.GroupVersionForDiscovery{
-								{
-									GroupVersion: "apps/v1",
-									Version:      "v1",
-								},
+			lock.Lock()
+			isDeleted := deleted[req.URL.Path]
+			lock.Unlock()
+			switch {
+			case req.URL.Query().Get("watch") == "true":
+				lock.Lock()
+				finalizers := gelatoFinalizers
+				lock.Unlock()
+				watchPods(w, req, finalizers)
+				return
+			case req.URL.Path == fudgePath && isDeleted:
+				response = nil
+			case (req.URL.Path == donutPath || req.URL.Path == eclairPath) && isDeleted:
+				pod := newPod("default", path.Base(req.URL.Path))
+				pod.ResourceVersion = "2"
+				response = pod
+			case req.URL.Path == gelatoPath && isDeleted:
+				lock.Lock()
+				pod := newPod("default", "gelato", gelatoFinalizers...)
+				lock.Unlock()
+				pod.ResourceVersion = "2"
+				response = pod
+			case req.URL.Path == muffinPath:
+				response = newMuffin(muffinVersion)
+			case isUnavailableGroup(req.URL.Path, unavailableGroups):
+				fmt.Printf("service unavailable: %s %s\n", req.Method, req.URL)
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			default:
+				response = getObject(req.URL.Path, req.URL.Query())
+			}
+			if groups, ok := response.(*metav1.APIGroupList); ok {
+				for _, g := range unavailableGroups {
+					groups.Groups = append(groups.Groups, metav1.APIGroup{
+						Name: g,
+						Versions: []metav1.GroupVersionForDiscovery{
+							{
+								GroupVersion: g + "/v1beta1",
+								Version:      "v1beta1",
 							},
 						},
-					},
-				}
-			case "/apis/customdomain/v1beta1":
-				response = &metav1.APIResourceList{
-					GroupVersion: "customdomain/v1beta1",
-					APIResources: []metav1.APIResource{
-						{
-							Name:         "customtypes",
-							SingularName: "customtype",
-							ShortNames:   []string{"ct"},
-							Namespaced:   true,
-							Kind:         "CustomType"},
-					},
-				}
-			case "/apis/apps/v1":
-				response = &metav1.APIResourceList{
-					GroupVersion: "apps/v1",
-					APIResources: []metav1.APIResource{
-						{
-							Name:         "deployments",
-							SingularName: "deployment",
-							ShortNames:   []string{"deploy"},
-							Namespaced:   true,
-							Kind:         "Deployment"},
-					},
-				}
-
-			case "/api/v1/namespaces/pasta":
-				response = corev1.Namespace{
-					TypeMeta: metav1.TypeMeta{
-						APIVersion: "v1",
-						Kind:       "Namespace",
-					},
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "pasta",
-					},
-					Spec: corev1.NamespaceSpec{
-						Finalizers: []corev1.FinalizerName{
-							corev1.FinalizerKubernetes,
-						},
-					},
-					Status: corev1.NamespaceStatus{
-						Phase: "Terminating",
-					},
-				}
-			case "/api/v1/namespaces/default/pods/cookie":
-				response = corev1.Pod{
-					TypeMeta: metav1.TypeMeta{
-						APIVersion: "v1",
-						Kind:       "Pod",
-					},
-					ObjectMeta: metav1.ObjectMeta{
-						Namespace: "default",
-						Name:      "cookie",
-						Finalizers: []string{
-							"cheesecake",
-						},
-					},
-					Spec: corev1.PodSpec{},
-					Status: corev1.PodStatus{
-						Phase: "Terminating",
-					},
-				}
-			case "/api/v1/namespaces/default/pods/cookie2":
-				response = corev1.Pod{
-					TypeMeta: metav1.TypeMeta{
-						APIVersion: "v1",
-						Kind:       "Pod",
-					},
-					ObjectMeta: metav1.ObjectMeta{
-						Namespace: "default",
-						Name:      "cookie2",
-						Finalizers: []string{
-							"cheesecake",
-						},
-					},
-					Spec: corev1.PodSpec{},
-					Status: corev1.PodStatus{
-						Phase: "Terminating",
-					},
+					})
 				}
-			case "/api/v1/namespaces/dessert/pods/cookie":
-				response = corev1.Pod{
-					TypeMeta: metav1.TypeMeta{
-						APIVersion: "v1",
-						Kind:       "Pod",
-					},
-					ObjectMeta: metav1.ObjectMeta{
-						Namespace: "dessert",
-						Name:      "cookie",
-						Finalizers: []string{
-							"cheesecake",
-						},
-					},
-					Spec: corev1.PodSpec{},
-					Status: corev1.PodStatus{
-						Phase: "Terminating",
-					},
-				}
-			case "/api/v1/namespaces/dessert/pods/cookie2": // no finalizer on this one
-				response = corev1.Pod{
-					TypeMeta: metav1.TypeMeta{
-						APIVersion: "v1",
-						Kind:       "Pod",
-					},
-					ObjectMeta: metav1.ObjectMeta{
-						Namespace: "dessert",
-						Name:      "cookie2",
-					},
-					Spec: corev1.PodSpec{},
-					Status: corev1.PodStatus{
-						Phase: "Terminating",
-					},
-				}
-			case "/apis/apps/v1/namespaces/default/deployments/latte": // no finalizer on this one
-				response = appsv1.Deployment{
-					TypeMeta: metav1.TypeMeta{
-						APIVersion: "v1",
-						Kind:       "Deployment",
-					},
-					ObjectMeta: metav1.ObjectMeta{
-						Namespace: "default",
-						Name:      "latte",
-					},
-					Spec: appsv1.DeploymentSpec{},
+			}
+			if response == nil {
+				fmt.Printf("object not found: %s %s\n", req.Method, req.URL)
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+		case "PATCH":
+			var object interface{}
+			switch req.URL.Path {
+			case muffinPath:
+				if muffinPatches == 0 {
+					// simulate a concurrent change on the resource between the GET and the first PATCH request
+					muffinVersion++
 				}
+				muffinPatches++
+				object = newMuffin(muffinVersion)
 			default:
+				object = getObject(req.URL.Path, req.URL.Query())
+			}
+			if object == nil {
 				fmt.Printf("object not found: %s %s\n", req.Method, req.URL)
 				w.WriteHeader(http.StatusNotFound)
 				return
 			}
+			if req.Header.Get("Content-Type") != string(types.JSONPatchType) {
+				fmt.Printf("unsupported patch type: %s\n", req.Header.Get("Content-Type"))
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+			data, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(err.Error())) // nolint: errcheck
+				return
+			}
+			// apply the patch on the predefined object, and return a 422 Unprocessable Entity error
+			// if one of its operations failed (eg: a `test` operation), as the API server does
+			original, err := json.Marshal(object)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(err.Error())) // nolint: errcheck
+				return
+			}
+			patch, err := jsonpatch.DecodePatch(data)
+			if err != nil {
+				writeStatus(w, metav1.Status{
+					Status:  metav1.StatusFailure,
+					Message: err.Error(),
+					Reason:  metav1.StatusReasonBadRequest,
+					Code:    http.StatusBadRequest,
+				})
+				return
+			}
+			patched, err := patch.Apply(original)
+			if err != nil {
+				fmt.Printf("unable to apply patch: %v\n", err)
+				writeStatus(w, metav1.Status{
+					Status:  metav1.StatusFailure,
+					Message: err.Error(),
+					Reason:  metav1.StatusReasonInvalid,
+					Code:    http.StatusUnprocessableEntity,
+				})
+				return
+			}
+			response = json.RawMessage(patched)
+			if req.URL.Path == gelatoPath {
+				// keep track of the finalizers which were not removed
+				pod := metav1.PartialObjectMetadata{}
+				if err := json.Unmarshal(patched, &pod); err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					w.Write([]byte(err.Error())) // nolint: errcheck
+					return
+				}
+				lock.Lock()
+				gelatoFinalizers = pod.Finalizers
+				lock.Unlock()
+			}
 		case "PUT":
 			switch req.URL.Path {
-			case "/api/v1/namespaces/cookie",
-				"/api/v1/namespaces/default/pods/cookie",
-				"/api/v1/namespaces/default/pods/cookie2",
-				"/api/v1/namespaces/dessert/pods/cookie",
-				"/api/v1/namespaces/dessert/pods/cookie2",
-				"/apis/apps/v1/namespaces/default/deployments/latte":
-
-				// here we want to verify that the resource in the incoming request has no finalizer in its metadata
-				// otherwise we return a 400 Bad Request error (unless there's something more appropriate?)
+			case "/api/v1/namespaces/pasta/finalize":
+				// here we want to verify that the namespace in the incoming request has no finalizer in its spec
+				// otherwise we return a 400 Bad Request error
 				data, err := ioutil.ReadAll(req.Body)
 				if err != nil {
 					w.WriteHeader(http.StatusInternalServerError)
 					w.Write([]byte(err.Error())) // nolint: errcheck
 					return
 				}
-				meta := metav1.ObjectMeta{}
-				err = json.Unmarshal(data, &meta)
+				ns := corev1.Namespace{}
+				err = json.Unmarshal(data, &ns)
 				if err != nil {
 					fmt.Printf("error while unmarshaling incoming request body: %v\n", err)
 					w.WriteHeader(http.StatusInternalServerError)
 					w.Write([]byte(err.Error())) // nolint: errcheck
 					return
 				}
-				if len(meta.GetFinalizers()) > 0 {
-					fmt.Printf("unexpected finalizers: %v\n", meta.GetFinalizers())
+				if len(ns.Spec.Finalizers) > 0 {
+					fmt.Printf("unexpected spec finalizers: %v\n", ns.Spec.Finalizers)
 					w.WriteHeader(http.StatusBadRequest)
-					// let's just return the request body in the response
-					response, _ := ioutil.ReadAll(req.Body)
-					w.Write(bytes.NewBuffer(response).Bytes()) // nolint: errcheck
+					w.Write(data) // nolint: errcheck
 					return
 				}
-				w.WriteHeader(http.StatusOK)
-				// let's just return the request body in the response
-				w.Write(data) // nolint: errcheck
+				// return the updated namespace, with a new resource version
+				ns.ResourceVersion = "2"
+				response = ns
+			}
+		case "POST":
+			// create the resource unless a predefined resource with the same name already exists
+			data, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(err.Error())) // nolint: errcheck
+				return
+			}
+			object := metav1.PartialObjectMetadata{}
+			if err := json.Unmarshal(data, &object); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error())) // nolint: errcheck
+				return
+			}
+			if getObject(req.URL.Path+"/"+object.Name, nil) != nil {
+				writeStatus(w, metav1.Status{
+					Status:  metav1.StatusFailure,
+					Message: fmt.Sprintf(`%s "%s" already exists`, path.Base(req.URL.Path), object.Name),
+					Reason:  metav1.StatusReasonAlreadyExists,
+					Code:    http.StatusConflict,
+				})
 				return
 			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			// let's just return the request body in the response
+			w.Write(data) // nolint: errcheck
+			return
 		case "DELETE":
 			switch req.URL.Path {
 			case "/api/v1/namespaces/cookie",
+				"/api/v1/namespaces/pasta",
 				"/api/v1/namespaces/default/pods/cookie",
 				"/api/v1/namespaces/default/pods/cookie2",
 				"/api/v1/namespaces/default/deploys/pasta",
-				"/api/v1/namespaces/dessert/pods/cookie":
+				"/api/v1/namespaces/default/pods/muffin",
+				"/api/v1/namespaces/pasta/pods/penne",
+				"/api/v1/namespaces/dessert/pods/cookie",
+				"/api/v1/namespaces/dessert/pods/cookie2",
+				"/apis/apps/v1/namespaces/default/deployments/latte":
 				// just accept the request
 				w.WriteHeader(http.StatusNoContent)
 				return
+			case donutPath, eclairPath:
+				lock.Lock()
+				deleted[req.URL.Path] = true
+				lock.Unlock()
+				w.WriteHeader(http.StatusNoContent)
+				return
+			case gelatoPath:
+				// the API server adds the finalizer of the propagation policy (if any)
+				data, err := ioutil.ReadAll(req.Body)
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					w.Write([]byte(err.Error())) // nolint: errcheck
+					return
+				}
+				opts := metav1.DeleteOptions{}
+				if err := json.Unmarshal(data, &opts); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(err.Error())) // nolint: errcheck
+					return
+				}
+				lock.Lock()
+				if opts.PropagationPolicy != nil && *opts.PropagationPolicy == metav1.DeletePropagationForeground {
+					gelatoFinalizers = append(gelatoFinalizers, metav1.FinalizerDeleteDependents)
+				} else if opts.PropagationPolicy != nil && *opts.PropagationPolicy == metav1.DeletePropagationOrphan {
+					gelatoFinalizers = append(gelatoFinalizers, metav1.FinalizerOrphanDependents)
+				}
+				deleted[req.URL.Path] = true
+				lock.Unlock()
+				w.WriteHeader(http.StatusNoContent)
+				return
+			case fudgePath:
+				// the kubelet on the node is not reachable, so the pod is only removed with a zero grace period
+				data, err := ioutil.ReadAll(req.Body)
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					w.Write([]byte(err.Error())) // nolint: errcheck
+					return
+				}
+				opts := metav1.DeleteOptions{}
+				if err := json.Unmarshal(data, &opts); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(err.Error())) // nolint: errcheck
+					return
+				}
+				if opts.GracePeriodSeconds != nil && *opts.GracePeriodSeconds == 0 {
+					lock.Lock()
+					deleted[req.URL.Path] = true
+					lock.Unlock()
+				}
+				response = getObject(req.URL.Path, nil)
+			case crumblePath:
+				writeStatus(w, metav1.Status{
+					Status:  metav1.StatusFailure,
+					Message: `pods "crumble" is forbidden: User "test" cannot delete resource "pods" in the namespace "default"`,
+					Reason:  metav1.StatusReasonForbidden,
+					Code:    http.StatusForbidden,
+				})
+				return
 			}
 		default:
 			fmt.Printf("unexpected request: %s %s\n", req.Method, req.URL)
@@ -268,3 +322,501 @@ func NewServer(t *testing.T) *httptest.Server {
 		w.Write(output) // nolint: errcheck
 	}))
 }
+
+// watchPods streams the watch events of the pods in the `default` namespace:
+// a `DELETED` event for the `donut` pod, a `MODIFIED` event for the `eclair` pod (with a new finalizer),
+// a `MODIFIED` event (with the given finalizers) followed by a `DELETED` event for the `gelato` pod,
+// and no event for the other pods, in which case the response remains open until the client closes the connection
+func watchPods(w http.ResponseWriter, req *http.Request, gelatoFinalizers []string) {
+	if req.URL.Path != "/api/v1/namespaces/default/pods" && req.URL.Path != "/api/v1/namespaces/dessert/pods" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	switch req.URL.Query().Get("fieldSelector") {
+	case "metadata.name=donut":
+		pod := newPod("default", "donut")
+		pod.ResourceVersion = "3"
+		json.NewEncoder(w).Encode(metav1.WatchEvent{ // nolint: errcheck
+			Type:   "DELETED",
+			Object: runtime.RawExtension{Object: &pod},
+		})
+	case "metadata.name=eclair":
+		pod := newPod("default", "eclair", "example.com/protection")
+		pod.ResourceVersion = "3"
+		json.NewEncoder(w).Encode(metav1.WatchEvent{ // nolint: errcheck
+			Type:   "MODIFIED",
+			Object: runtime.RawExtension{Object: &pod},
+		})
+	case "metadata.name=gelato":
+		pod := newPod("default", "gelato", gelatoFinalizers...)
+		pod.ResourceVersion = "3"
+		json.NewEncoder(w).Encode(metav1.WatchEvent{ // nolint: errcheck
+			Type:   "MODIFIED",
+			Object: runtime.RawExtension{Object: &pod},
+		})
+		pod.ResourceVersion = "4"
+		json.NewEncoder(w).Encode(metav1.WatchEvent{ // nolint: errcheck
+			Type:   "DELETED",
+			Object: runtime.RawExtension{Object: &pod},
+		})
+	}
+	w.(http.Flusher).Flush()
+	<-req.Context().Done()
+}
+
+// writeStatus writes the given status in the response, as the API server does when a request fails
+func writeStatus(w http.ResponseWriter, status metav1.Status) {
+	status.TypeMeta = metav1.TypeMeta{
+		APIVersion: "v1",
+		Kind:       "Status",
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(int(status.Code))
+	json.NewEncoder(w).Encode(status) // nolint: errcheck
+}
+
+// getObject returns the predefined object (or list of objects) at the given path, or `nil` if none exists
+func getObject(path string, query url.Values) interface{} {
+	switch path {
+	case "/api/v1":
+		return &metav1.APIResourceList{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{
+					Name:       "namespaces",
+					ShortNames: []string{"ns"},
+					Namespaced: false,
+					Kind:       "Namespace",
+					Verbs:      Verbs,
+				},
+				{
+					Name:         "pods",
+					SingularName: "pod",
+					ShortNames:   []string{"po"},
+					Namespaced:   true,
+					Kind:         "Pod",
+					Verbs:        Verbs,
+				},
+			},
+		}
+	case "/api":
+		return &metav1.APIVersions{
+			Versions: []string{
+				"v1",
+			},
+		}
+	case "/apis":
+		return &metav1.APIGroupList{
+			Groups: []metav1.APIGroup{
+				{
+					Name: "customdomain",
+					Versions: []metav1.GroupVersionForDiscovery{
+						{
+							GroupVersion: "customdomain/v1beta1",
+							Version:      "v1beta1",
+						},
+						{
+							GroupVersion: "customdomain/v1alpha1",
+							Version:      "v1alpha1",
+						},
+					},
+					PreferredVersion: metav1.GroupVersionForDiscovery{
+						GroupVersion: "customdomain/v1beta1",
+						Version:      "v1beta1",
+					},
+				},
+				{
+					Name: "apps",
+					Versions: []metav1.GroupVersionForDiscovery{
+						{
+							GroupVersion: "apps/v1",
+							Version:      "v1",
+						},
+					},
+				},
+				{
+					Name: "bakery.example.com",
+					Versions: []metav1.GroupVersionForDiscovery{
+						{
+							GroupVersion: "bakery.example.com/v1",
+							Version:      "v1",
+						},
+					},
+				},
+				{
+					Name: "patisserie.example.com",
+					Versions: []metav1.GroupVersionForDiscovery{
+						{
+							GroupVersion: "patisserie.example.com/v1alpha1",
+							Version:      "v1alpha1",
+						},
+					},
+				},
+			},
+		}
+	case "/apis/customdomain/v1beta1":
+		return &metav1.APIResourceList{
+			GroupVersion: "customdomain/v1beta1",
+			APIResources: []metav1.APIResource{
+				{
+					Name:         "customtypes",
+					SingularName: "customtype",
+					ShortNames:   []string{"ct"},
+					Namespaced:   true,
+					Kind:         "CustomType",
+					Verbs:        Verbs,
+				},
+			},
+		}
+	case "/apis/customdomain/v1alpha1": // older version, which is still served
+		return &metav1.APIResourceList{
+			GroupVersion: "customdomain/v1alpha1",
+			APIResources: []metav1.APIResource{
+				{
+					Name:         "customtypes",
+					SingularName: "customtype",
+					ShortNames:   []string{"ct"},
+					Namespaced:   true,
+					Kind:         "CustomType",
+					Verbs:        Verbs,
+				},
+			},
+		}
+	case "/apis/bakery.example.com/v1": // some types have the same names as in other groups
+		return &metav1.APIResourceList{
+			GroupVersion: "bakery.example.com/v1",
+			APIResources: []metav1.APIResource{
+				{
+					Name:         "cakes",
+					SingularName: "cake",
+					ShortNames:   []string{"ck"},
+					Kind:         "Cake",
+					Verbs:        unlistableVerbs,
+				},
+				{
+					Name:         "potatoes",
+					SingularName: "potato",
+					ShortNames:   []string{"po"},
+					Kind:         "Potato",
+					Verbs:        unlistableVerbs,
+				},
+			},
+		}
+	case "/apis/patisserie.example.com/v1alpha1":
+		return &metav1.APIResourceList{
+			GroupVersion: "patisserie.example.com/v1alpha1",
+			APIResources: []metav1.APIResource{
+				{
+					Name:         "cupcakes",
+					SingularName: "cupcake",
+					ShortNames:   []string{"ck"},
+					Kind:         "Cupcake",
+					Verbs:        unlistableVerbs,
+				},
+			},
+		}
+	case "/apis/apps/v1":
+		return &metav1.APIResourceList{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{
+					Name:         "deployments",
+					SingularName: "deployment",
+					ShortNames:   []string{"deploy"},
+					Namespaced:   true,
+					Kind:         "Deployment",
+					Verbs:        Verbs,
+				},
+			},
+		}
+
+	case "/api/v1/namespaces": // all namespaces
+		return corev1.NamespaceList{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "NamespaceList",
+			},
+			Items: []corev1.Namespace{
+				{
+					TypeMeta: metav1.TypeMeta{
+						APIVersion: "v1",
+						Kind:       "Namespace",
+					},
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "default",
+						ResourceVersion: "1",
+					},
+				},
+				newPasta(),
+			},
+		}
+	case "/api/v1/namespaces/pasta":
+		return newPasta()
+	case "/api/v1/pods": // pods in all namespaces
+		return corev1.PodList{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "PodList",
+			},
+			Items: []corev1.Pod{
+				newPod("default", "cookie", "cheesecake"),
+				newPod("default", "fudge"),
+				newPenne(),
+			},
+		}
+	case "/apis/apps/v1/deployments", "/apis/customdomain/v1beta1/customtypes": // no resources in all namespaces
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "List",
+			"items":      []interface{}{},
+		}
+	case "/api/v1/namespaces/pasta/pods":
+		return corev1.PodList{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "PodList",
+			},
+			Items: []corev1.Pod{
+				newPenne(),
+			},
+		}
+	case "/api/v1/namespaces/pasta/pods/penne": // owned by a deployment which does not exist anymore
+		return newPenne()
+	case crumblePath: // cannot be deleted
+		return newPod("default", "crumble", "cheesecake")
+	case fudgePath: // on an unreachable node
+		return newPod("default", "fudge")
+	case donutPath: // removed after it was deleted
+		return newPod("default", "donut", "cheesecake")
+	case eclairPath: // blocked again after it was deleted
+		return newPod("default", "eclair", "cheesecake")
+	case gelatoPath: // keeps the finalizers which were not removed after it was deleted
+		return newPod("default", "gelato", "cheesecake", "example.com/keep")
+	case "/apis/apps/v1/namespaces/pasta/deployments":
+		return appsv1.DeploymentList{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "apps/v1",
+				Kind:       "DeploymentList",
+			},
+			Items: []appsv1.Deployment{},
+		}
+	case "/apis/customdomain/v1beta1/namespaces/pasta/customtypes":
+		return map[string]interface{}{
+			"apiVersion": "customdomain/v1beta1",
+			"kind":       "CustomTypeList",
+			"items":      []interface{}{},
+		}
+	case "/api/v1/namespaces/default/pods":
+		// only a few selectors are supported here
+		items := []corev1.Pod{}
+		switch {
+		case query.Get("labelSelector") == "app=cookies":
+			items = append(items, newPod("default", "cookie", "cheesecake"), newPod("default", "cookie2", "cheesecake"))
+		case query.Get("fieldSelector") == "metadata.name=cookie":
+			items = append(items, newPod("default", "cookie", "cheesecake"))
+		}
+		return corev1.PodList{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "PodList",
+			},
+			Items: items,
+		}
+	case "/api/v1/namespaces/default/pods/cookie":
+		return corev1.Pod{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Pod",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         "default",
+				Name:              "cookie",
+				DeletionTimestamp: &DeletionTimestamp,
+				ResourceVersion:   "1",
+				Finalizers: []string{
+					"cheesecake",
+				},
+			},
+			Spec: corev1.PodSpec{},
+			Status: corev1.PodStatus{
+				Phase: "Terminating",
+			},
+		}
+	case "/api/v1/namespaces/default/pods/cookie2":
+		return corev1.Pod{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Pod",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         "default",
+				Name:              "cookie2",
+				DeletionTimestamp: &DeletionTimestamp,
+				ResourceVersion:   "1",
+				Finalizers: []string{
+					"cheesecake",
+				},
+			},
+			Spec: corev1.PodSpec{},
+			Status: corev1.PodStatus{
+				Phase: "Terminating",
+			},
+		}
+	case "/api/v1/namespaces/dessert/pods/cookie":
+		return corev1.Pod{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Pod",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         "dessert",
+				Name:              "cookie",
+				DeletionTimestamp: &DeletionTimestamp,
+				ResourceVersion:   "1",
+				Finalizers: []string{
+					"cheesecake",
+				},
+			},
+			Spec: corev1.PodSpec{},
+			Status: corev1.PodStatus{
+				Phase: "Terminating",
+			},
+		}
+	case "/api/v1/namespaces/dessert/pods/cookie2": // no finalizer on this one
+		return corev1.Pod{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Pod",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         "dessert",
+				Name:              "cookie2",
+				DeletionTimestamp: &DeletionTimestamp,
+				ResourceVersion:   "1",
+			},
+			Spec: corev1.PodSpec{},
+			Status: corev1.PodStatus{
+				Phase: "Terminating",
+			},
+		}
+	case "/apis/apps/v1/namespaces/default/deployments/latte": // no finalizer and not being deleted on this one
+		return appsv1.Deployment{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Deployment",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       "default",
+				Name:            "latte",
+				ResourceVersion: "1",
+			},
+			Spec: appsv1.DeploymentSpec{},
+		}
+	default:
+		return nil
+	}
+}
+
+// isUnavailableGroup returns 'true' if the given path belongs to one of the given unavailable API groups
+func isUnavailableGroup(path string, unavailableGroups []string) bool {
+	for _, g := range unavailableGroups {
+		if strings.HasPrefix(path, "/apis/"+g+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Verbs the verbs supported by the predefined API resources
+var Verbs = metav1.Verbs{"delete", "get", "list", "patch", "update"}
+
+// unlistableVerbs the verbs supported by the predefined API resources which are only used to resolve resource types
+var unlistableVerbs = metav1.Verbs{"delete", "get", "patch", "update"}
+
+// newPasta returns the `pasta` namespace, which is stuck because of the remaining pods with finalizers
+func newPasta() corev1.Namespace {
+	return corev1.Namespace{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Namespace",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "pasta",
+			DeletionTimestamp: &DeletionTimestamp,
+			ResourceVersion:   "1",
+		},
+		Spec: corev1.NamespaceSpec{
+			Finalizers: []corev1.FinalizerName{
+				corev1.FinalizerKubernetes,
+			},
+		},
+		Status: corev1.NamespaceStatus{
+			Phase: "Terminating",
+			Conditions: []corev1.NamespaceCondition{
+				{
+					Type:    corev1.NamespaceFinalizersRemaining,
+					Status:  corev1.ConditionTrue,
+					Reason:  "SomeFinalizersRemain",
+					Message: "Some content in the namespace has finalizers remaining: cheesecake in 1 resource instances",
+				},
+			},
+		},
+	}
+}
+
+func newPenne() corev1.Pod {
+	pod := newPod("pasta", "penne", "cheesecake")
+	pod.OwnerReferences = []metav1.OwnerReference{
+		{
+			APIVersion:         "apps/v1",
+			Kind:               "Deployment",
+			Name:               "rigatoni",
+			UID:                "rigatoni-uid",
+			BlockOwnerDeletion: &blockOwnerDeletion,
+		},
+	}
+	return pod
+}
+
+var blockOwnerDeletion = true
+
+const muffinPath = "/api/v1/namespaces/default/pods/muffin"
+
+const crumblePath = "/api/v1/namespaces/default/pods/crumble"
+
+const donutPath = "/api/v1/namespaces/default/pods/donut"
+
+const fudgePath = "/api/v1/namespaces/default/pods/fudge"
+
+const eclairPath = "/api/v1/namespaces/default/pods/eclair"
+
+const gelatoPath = "/api/v1/namespaces/default/pods/gelato"
+
+// newMuffin returns the `muffin` pod with the given resource version
+func newMuffin(version int) corev1.Pod {
+	pod := newPod("default", "muffin", "cheesecake", "blueberry")
+	pod.ResourceVersion = strconv.Itoa(version)
+	return pod
+}
+
+func newPod(namespace, name string, finalizers ...string) corev1.Pod {
+	return corev1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         namespace,
+			Name:              name,
+			DeletionTimestamp: &DeletionTimestamp,
+			ResourceVersion:   "1",
+			Finalizers:        finalizers,
+		},
+		Spec: corev1.PodSpec{},
+		Status: corev1.PodStatus{
+			Phase: "Terminating",
+		},
+	}
+}
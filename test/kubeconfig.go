@@ -3,6 +3,7 @@ package test
 import (
 	"bytes"
 	"html/template"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -17,11 +18,33 @@ clusters:
 - cluster:
     server: "{{ . }}"
   name: test-server
+- cluster:
+    server: "http://127.0.0.1:1"
+  name: unreachable
+users:
+- name: test
+  user: {}
 contexts:
 - context:
     cluster: test-server
+    user: test
   name: test-server
+- context:
+    cluster: unreachable
+  name: unreachable
 current-context: test-server`
+
+	contextKubeconfigTmpl = `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: "{{ .ServerURL }}"
+  name: {{ .Context }}
+contexts:
+- context:
+    cluster: {{ .Context }}
+  name: {{ .Context }}
+current-context: {{ .Context }}`
 )
 
 // NewKubeConfigFile returns the path to a the kubeconfig file to access
@@ -53,3 +76,23 @@ func NewKubeConfigContent(t *testing.T, serverURL string) []byte {
 	require.NoError(t, err)
 	return r.Bytes()
 }
+
+// NewContextKubeConfigFile returns a temporary kubeconfig file with a single cluster and context
+// with the given name, to access the server with the given URL
+func NewContextKubeConfigFile(t *testing.T, context, serverURL string) *os.File {
+	tmpl, err := template.New("kubeconfig").Parse(contextKubeconfigTmpl)
+	require.NoError(t, err)
+	f, err := ioutil.TempFile("", context+"-*.yaml")
+	require.NoError(t, err)
+	err = tmpl.Execute(f, struct {
+		Context   string
+		ServerURL string
+	}{
+		Context:   context,
+		ServerURL: serverURL,
+	})
+	require.NoError(t, err)
+	err = f.Close()
+	require.NoError(t, err)
+	return f
+}